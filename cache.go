@@ -0,0 +1,46 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"github.com/ethereum/go-ethereum/common/lru"
+
+	"go.0xjac.com/tfmpt/node"
+)
+
+// CacheStats are the counters of the cache of decoded nodes of a trie.
+type CacheStats struct {
+	Hits   uint64 // Hits is the number of nodes found in the cache.
+	Misses uint64 // Misses is the number of nodes loaded from the store.
+	Nodes  int    // Nodes is the number of nodes in the cache.
+}
+
+// nodeCache is a size-bounded LRU cache of decoded nodes keyed by hash, safe for concurrent use.
+// Nodes are keyed by hash rather than path as the node with a given hash is the same at any path.
+// Cached nodes are shared, so they must be copied before being modified.
+type nodeCache = lru.Cache[string, node.Node]
+
+// CacheStats returns the counters of the cache of decoded nodes, which are zero if the cache is disabled.
+func (t *Trie) CacheStats() CacheStats {
+	if t.cache == nil {
+		return CacheStats{}
+	}
+
+	return CacheStats{Hits: t.cacheHits.Load(), Misses: t.cacheMisses.Load(), Nodes: t.cache.Len()}
+}
+
+// cached returns the decoded node with the hash from the cache, if it is enabled and holds the node.
+func (t *Trie) cached(hashed node.Hashed) (node.Node, bool) {
+	if t.cache == nil {
+		return nil, false
+	}
+
+	n, ok := t.cache.Get(string(hashed))
+	if ok {
+		t.cacheHits.Add(1)
+	} else {
+		t.cacheMisses.Add(1)
+	}
+
+	return n, ok
+}
@@ -0,0 +1,128 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/trie"
+
+	"go.0xjac.com/tfmpt/store"
+)
+
+func TestTrieCache(t *testing.T) {
+	db, root, pairs := committedTrieFixture(t, 300)
+
+	mpt := LoadTrie(db, root, WithCache(1000))
+	for _, p := range pairs {
+		val, err := mpt.Get(p.key)
+		assertPresent(t, p.key, val, p.val, err)
+	}
+
+	stats := mpt.CacheStats()
+	if stats.Misses == 0 || stats.Nodes != int(stats.Misses) {
+		t.Errorf("Expected every missed node to be cached, got stats=%+v", stats)
+	}
+
+	// The second lookups are only served from the cache, even without the store.
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range pairs {
+		val, err := mpt.Get(p.key)
+		assertPresent(t, p.key, val, p.val, err)
+	}
+
+	if after := mpt.CacheStats(); after.Misses != stats.Misses || after.Hits <= stats.Hits {
+		t.Errorf("Expected only cache hits, got stats=%+v after stats=%+v", after, stats)
+	}
+}
+
+func TestTrieCacheEviction(t *testing.T) {
+	db, root, pairs := committedTrieFixture(t, 300)
+
+	mpt := LoadTrie(db, root, WithCache(4))
+	for _, p := range pairs {
+		val, err := mpt.Get(p.key)
+		assertPresent(t, p.key, val, p.val, err)
+	}
+
+	if stats := mpt.CacheStats(); stats.Nodes != 4 {
+		t.Errorf("Expected the cache to hold 4 nodes, got stats=%+v", stats)
+	}
+
+	if stats := LoadTrie(db, root).CacheStats(); stats != (CacheStats{}) {
+		t.Errorf("Expected no stats without cache, got stats=%+v", stats)
+	}
+}
+
+func TestTrieCacheUpdate(t *testing.T) {
+	db, root, pairs := committedTrieFixture(t, 300)
+
+	// Cached nodes must not be modified by updates, so both tries must stay in sync.
+	cached, uncached := LoadTrie(db, root, WithCache(1000)), NewEmptyTrie(nil)
+	for _, p := range pairs {
+		uncached.Put(p.key, p.val)
+	}
+
+	for i, p := range pairs {
+		if i%2 == 0 {
+			cached.Put(p.key, append([]byte("<new_val>"), p.val...))
+			uncached.Put(p.key, append([]byte("<new_val>"), p.val...))
+		} else if err := cached.Del(p.key); err != nil {
+			t.Fatalf("Expected key=%x to be deleted, got err=%s", p.key, err)
+		} else if err = uncached.Del(p.key); err != nil {
+			t.Fatalf("Expected key=%x to be deleted, got err=%s", p.key, err)
+		}
+
+		if i%50 == 0 {
+			if _, err := cached.Commit(); err != nil {
+				t.Fatalf("Expected trie to be committed, got err=%s", err)
+			}
+		}
+
+		if hash, expected := cached.Hash(), uncached.Hash(); !bytes.Equal(hash, expected) {
+			t.Fatalf("Expected root=%064x after %d updates, got root=%064x", expected, i+1, hash)
+		}
+	}
+}
+
+func TestTrieCacheSharedNodes(t *testing.T) {
+	db := store.NewMemoryDB()
+	mpt, ethMPT := NewEmptyTrie(db), trie.NewEmpty(nil)
+
+	// The subtrees under 0x1 and 0x2 are identical, so their nodes share the same cached node.
+	value := bytes.Repeat([]byte{0xff}, 40)
+	for _, prefix := range []byte{0x10, 0x20} {
+		for _, suffix := range []byte{0x21, 0x22, 0x31, 0x32} {
+			mpt.Put([]byte{prefix, 0x00, suffix}, value)
+			ethMPT.MustUpdate([]byte{prefix, 0x00, suffix}, value)
+		}
+	}
+
+	root, err := mpt.Commit()
+	if err != nil {
+		t.Fatalf("Expected trie to be committed, got err=%s", err)
+	}
+
+	// Both subtrees merge their extension with a different child extension.
+	mpt = LoadTrie(db, root, WithCache(100))
+	for _, key := range [][]byte{{0x10, 0x00, 0x31}, {0x10, 0x00, 0x32}, {0x20, 0x00, 0x21}, {0x20, 0x00, 0x22}} {
+		if err = mpt.Del(key); err != nil {
+			t.Fatalf("Expected key=%x to be deleted, got err=%s", key, err)
+		}
+
+		ethMPT.MustDelete(key)
+	}
+
+	if actual, expected := mpt.Hash(), ethMPT.Hash(); !bytes.Equal(actual, expected[:]) {
+		t.Errorf("Expected root=%064x, got root=%064x", expected, actual)
+	}
+
+	for _, key := range [][]byte{{0x10, 0x00, 0x21}, {0x10, 0x00, 0x22}, {0x20, 0x00, 0x31}, {0x20, 0x00, 0x32}} {
+		val, err := mpt.Get(key)
+		assertPresent(t, key, val, value, err)
+	}
+}
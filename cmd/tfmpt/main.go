@@ -0,0 +1,335 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+// Command tfmpt inspects the tries committed to a store, and moves them between stores.
+//
+// Usage:
+//
+//	tfmpt verify -db <dir> -root <hash> [-backend leveldb|pebble] [-scheme path|hash] [-owner <hex>]
+//	tfmpt export -db <dir> -root <hash> [-out <file>] [-backend leveldb|pebble] [-scheme path|hash] [-owner <hex>]
+//	tfmpt import -db <dir> [-in <file>] [-backend leveldb|pebble] [-scheme path|hash] [-owner <hex>]
+//
+// Export writes to the standard output and import reads from the standard input by default.
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.0xjac.com/tfmpt"
+	"go.0xjac.com/tfmpt/store"
+)
+
+const (
+	exitOK      = 0
+	exitFailure = 1 // exitFailure indicates the command ran but found problems.
+	exitError   = 2 // exitError indicates the command could not run.
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: tfmpt <command> [flags]\n\ncommands:\n"+
+			"  verify  check the integrity of a trie\n"+
+			"  export  write a trie to a portable file\n"+
+			"  import  rebuild a trie from a portable file")
+		return exitError
+	}
+
+	switch args[0] {
+	case "verify":
+		return runVerify(args[1:], stdout, stderr)
+	case "export":
+		return runExport(args[1:], stdout, stderr)
+	case "import":
+		return runImport(args[1:], stdin, stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+		return exitError
+	}
+}
+
+func runVerify(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	var cfg storeFlags
+	cfg.register(flags)
+	rootHex := flags.String("root", "", "hex-encoded root hash of the trie")
+
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+
+	root, err := decodeHex(*rootHex)
+	if err != nil || len(root) != 32 {
+		fmt.Fprintf(stderr, "invalid root %q\n", *rootHex)
+		return exitError
+	}
+
+	opts, err := cfg.options()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	db, err := cfg.open(true)
+	if err != nil {
+		fmt.Fprintf(stderr, "cannot open store: %s\n", err)
+		return exitError
+	}
+	defer db.Close()
+
+	report, err := tfmpt.Verify(db, root, opts...)
+	if err != nil {
+		fmt.Fprintf(stderr, "cannot verify trie: %s\n", err)
+		return exitError
+	}
+
+	fmt.Fprintf(stdout, "nodes: %d\n", report.Nodes)
+	for _, missing := range report.Missing {
+		fmt.Fprintln(stdout, missing)
+	}
+
+	for _, decode := range report.Decode {
+		fmt.Fprintln(stdout, decode)
+	}
+
+	for _, corrupt := range report.Corrupt {
+		fmt.Fprintln(stdout, corrupt)
+	}
+
+	for _, orphan := range report.Orphans {
+		fmt.Fprintf(stdout, "orphan node at key %x\n", orphan)
+	}
+
+	if !report.OK() {
+		fmt.Fprintf(stdout, "FAIL: %d missing, %d undecodable, %d corrupt, %d orphans\n",
+			len(report.Missing), len(report.Decode), len(report.Corrupt), len(report.Orphans))
+		return exitFailure
+	}
+
+	fmt.Fprintln(stdout, "OK")
+
+	return exitOK
+}
+
+func runExport(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	var cfg storeFlags
+	cfg.register(flags)
+	rootHex := flags.String("root", "", "hex-encoded root hash of the trie")
+	outPath := flags.String("out", "", "file to write the export to, instead of the standard output")
+
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+
+	root, err := decodeHex(*rootHex)
+	if err != nil || len(root) != 32 {
+		fmt.Fprintf(stderr, "invalid root %q\n", *rootHex)
+		return exitError
+	}
+
+	opts, err := cfg.options()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	db, err := cfg.open(true)
+	if err != nil {
+		fmt.Fprintf(stderr, "cannot open store: %s\n", err)
+		return exitError
+	}
+	defer db.Close()
+
+	export := func(w io.Writer) error {
+		out := bufio.NewWriter(w)
+		if err := tfmpt.Export(out, db, root, opts...); err != nil {
+			return err
+		}
+
+		return out.Flush()
+	}
+
+	if *outPath == "" {
+		err = export(stdout)
+	} else {
+		err = writeFile(*outPath, export)
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "cannot export trie: %s\n", err)
+		return exitError
+	}
+
+	return exitOK
+}
+
+func runImport(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	var cfg storeFlags
+	cfg.register(flags)
+	inPath := flags.String("in", "", "file to read the export from, instead of the standard input")
+
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+
+	opts, err := cfg.options()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitError
+	}
+
+	in := stdin
+	if *inPath != "" {
+		file, err := os.Open(*inPath)
+		if err != nil {
+			fmt.Fprintf(stderr, "cannot open export: %s\n", err)
+			return exitError
+		}
+		defer file.Close()
+
+		in = file
+	}
+
+	// Nothing is written to the store if the import fails, so a store created for it is removed.
+	_, err = os.Stat(cfg.dbPath)
+	created := errors.Is(err, fs.ErrNotExist)
+
+	db, err := cfg.open(false)
+	if err != nil {
+		fmt.Fprintf(stderr, "cannot open store: %s\n", err)
+		return exitError
+	}
+
+	mpt, err := tfmpt.Import(in, db, opts...)
+	if closeErr := db.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil && created {
+		_ = os.RemoveAll(cfg.dbPath)
+	}
+
+	if errors.Is(err, tfmpt.ErrInvalidExport) {
+		fmt.Fprintln(stdout, err)
+		return exitFailure
+	} else if err != nil {
+		fmt.Fprintf(stderr, "cannot import trie: %s\n", err)
+		return exitError
+	}
+
+	fmt.Fprintf(stdout, "root: %064x\n", mpt.Hash())
+
+	return exitOK
+}
+
+// storeFlags are the flags to open a store and the tries in it.
+type storeFlags struct {
+	dbPath  string
+	backend string
+	scheme  string
+	owner   string
+}
+
+func (f *storeFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&f.dbPath, "db", "", "directory of the store")
+	flags.StringVar(&f.backend, "backend", "leveldb", "backend of the store: leveldb or pebble")
+	flags.StringVar(&f.scheme, "scheme", tfmpt.PathScheme.String(), "layout of the nodes in the store: path or hash")
+	flags.StringVar(&f.owner, "owner", "", "hex-encoded owner of the trie, if any")
+}
+
+func (f *storeFlags) options() ([]tfmpt.Option, error) {
+	var opts []tfmpt.Option
+
+	switch f.scheme {
+	case tfmpt.PathScheme.String():
+		opts = append(opts, tfmpt.WithScheme(tfmpt.PathScheme))
+	case tfmpt.HashScheme.String():
+		opts = append(opts, tfmpt.WithScheme(tfmpt.HashScheme))
+	default:
+		return nil, fmt.Errorf("unknown scheme %q", f.scheme)
+	}
+
+	if f.owner != "" {
+		owner, err := decodeHex(f.owner)
+		if err != nil {
+			return nil, fmt.Errorf("invalid owner %q: %w", f.owner, err)
+		}
+
+		opts = append(opts, tfmpt.WithOwner(owner))
+	}
+
+	return opts, nil
+}
+
+func (f *storeFlags) open(readOnly bool) (store.DB, error) {
+	if f.dbPath == "" {
+		return nil, errors.New("missing -db")
+	}
+
+	switch f.backend {
+	case "leveldb":
+		if readOnly {
+			return store.NewLevelDBReadOnly(f.dbPath)
+		}
+
+		return store.NewLevelDB(f.dbPath)
+	case "pebble":
+		if readOnly {
+			return store.NewPebbleReadOnly(f.dbPath)
+		}
+
+		return store.NewPebble(f.dbPath)
+	default:
+		return nil, fmt.Errorf("unknown backend %q", f.backend)
+	}
+}
+
+// writeFile writes to a temporary file which replaces the file at the path once fully written,
+// so that a failure never leaves a partial file behind.
+func writeFile(path string, write func(io.Writer) error) (err error) {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = file.Close() // The file may already be closed, in which case the error is expected.
+			_ = os.Remove(file.Name())
+		}
+	}()
+
+	// The temporary file is only readable by its owner, unlike a file from os.Create.
+	if err = file.Chmod(0o644); err != nil {
+		return err
+	} else if err = write(file); err != nil {
+		return err
+	} else if err = file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+func decodeHex(s string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"))
+}
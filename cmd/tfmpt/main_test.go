@@ -0,0 +1,146 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.0xjac.com/tfmpt"
+	"go.0xjac.com/tfmpt/store"
+)
+
+func TestVerify(t *testing.T) {
+	dbPath, root := storeFixture(t)
+
+	if code, stdout, stderr := runFixture(t, "verify", "-db", dbPath, "-root", root); code != exitOK {
+		t.Errorf("Expected exit code %d, got code=%d stdout=%q stderr=%q", exitOK, code, stdout, stderr)
+	} else if !strings.HasSuffix(stdout, "OK\n") {
+		t.Errorf("Expected OK, got stdout=%q", stdout)
+	}
+
+	// Replace the root node with an undecodable one.
+	db, err := store.NewLevelDB(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	} else if err = db.Put(nil, []byte{0xc0}); err != nil {
+		t.Fatal(err)
+	} else if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if code, stdout, stderr := runFixture(t, "verify", "-db", dbPath, "-root", root); code != exitFailure {
+		t.Errorf("Expected exit code %d, got code=%d stdout=%q stderr=%q", exitFailure, code, stdout, stderr)
+	} else if !strings.Contains(stdout, "FAIL") {
+		t.Errorf("Expected FAIL, got stdout=%q", stdout)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	dbPath, root := storeFixture(t)
+	exportPath := filepath.Join(t.TempDir(), "trie.export")
+
+	if code, stdout, stderr := runFixture(t, "export", "-db", dbPath, "-root", root, "-out", exportPath); code != exitOK {
+		t.Fatalf("Expected exit code %d, got code=%d stdout=%q stderr=%q", exitOK, code, stdout, stderr)
+	}
+
+	if info, err := os.Stat(exportPath); err != nil {
+		t.Fatal(err)
+	} else if info.Mode().Perm() != 0o644 {
+		t.Errorf("Expected export mode=%s, got mode=%s", fs.FileMode(0o644), info.Mode().Perm())
+	}
+
+	// A truncated export is rejected, without leaving a store behind.
+	raw, err := os.ReadFile(exportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	truncatedPath := filepath.Join(t.TempDir(), "truncated.export")
+	if err = os.WriteFile(truncatedPath, raw[:len(raw)/2], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rejectedPath := filepath.Join(t.TempDir(), "db")
+	code, stdout, stderr := runFixture(t, "import", "-in", truncatedPath, "-db", rejectedPath, "-backend", "pebble")
+	if code != exitFailure {
+		t.Errorf("Expected exit code %d, got code=%d stdout=%q stderr=%q", exitFailure, code, stdout, stderr)
+	} else if _, err = os.Stat(rejectedPath); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected no store to be left behind, got err=%v", err)
+	}
+
+	// The trie is imported in another backend, with another scheme.
+	importPath := filepath.Join(t.TempDir(), "db")
+	importArgs := []string{"-db", importPath, "-backend", "pebble", "-scheme", "hash"}
+
+	code, stdout, stderr = runFixture(t, append([]string{"import", "-in", exportPath}, importArgs...)...)
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got code=%d stdout=%q stderr=%q", exitOK, code, stdout, stderr)
+	} else if expected := fmt.Sprintf("root: %s\n", root); stdout != expected {
+		t.Errorf("Expected stdout=%q, got stdout=%q", expected, stdout)
+	}
+
+	if code, stdout, stderr = runFixture(t, append([]string{"verify", "-root", root}, importArgs...)...); code != exitOK {
+		t.Errorf("Expected exit code %d, got code=%d stdout=%q stderr=%q", exitOK, code, stdout, stderr)
+	}
+}
+
+func TestInvalidArgs(t *testing.T) {
+	dbPath, _ := storeFixture(t)
+
+	for _, args := range [][]string{
+		{},
+		{"unknown"},
+		{"verify", "-db", dbPath, "-root", "0x1234"},
+		{"verify", "-db", dbPath, "-root", "<root>"},
+		{"export", "-db", dbPath, "-root", "0x1234"},
+		{"verify", "-db", dbPath, "-root", strings.Repeat("00", 32), "-scheme", "unknown"},
+	} {
+		if code, stdout, stderr := runFixture(t, args...); code != exitError {
+			t.Errorf("Expected exit code %d for args=%q, got code=%d stdout=%q stderr=%q",
+				exitError, args, code, stdout, stderr)
+		}
+	}
+}
+
+// storeFixture returns the path of a LevelDB store with a trie committed in it, and its hex-encoded root.
+func storeFixture(t *testing.T) (string, string) {
+	t.Helper()
+
+	dbPath := filepath.Join(t.TempDir(), "db")
+
+	db, err := store.NewLevelDB(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mpt := tfmpt.NewEmptyTrie(db)
+	for i := 0; i < 100; i++ {
+		if err = mpt.Update([]byte(fmt.Sprintf("<key_%d>", i)), []byte(fmt.Sprintf("<val_%d>", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	root, err := mpt.Commit()
+	if err != nil {
+		t.Fatalf("Expected trie to be committed, got err=%s", err)
+	}
+
+	return dbPath, fmt.Sprintf("%064x", root)
+}
+
+func runFixture(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(""), &stdout, &stderr)
+
+	return code, stdout.String(), stderr.String()
+}
@@ -28,3 +28,8 @@ func Keccak256(data ...[]byte) []byte {
 	d.Read(b)
 	return b
 }
+
+// NewKeccakState creates a new KeccakState, to hash data written to it over time.
+func NewKeccakState() KeccakState {
+	return sha3.NewLegacyKeccak256().(KeccakState)
+}
@@ -0,0 +1,242 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"go.0xjac.com/tfmpt/crypto"
+)
+
+var (
+	ErrAccountMismatch = errors.New("account does not match its proof")
+	ErrStorageMismatch = errors.New("storage value does not match its proof")
+
+	// emptyCodeHash is the precomputed hash of an empty code.
+	// It is equivalent to keccak256(nil).
+	emptyCodeHash = common.HexToHash("0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470")
+)
+
+// StateAccount is the value of an account in the state trie, keyed by the hash of its address.
+type StateAccount struct {
+	Nonce    uint64
+	Balance  *big.Int
+	Root     []byte // Root is the root hash of the storage trie of the account.
+	CodeHash []byte
+}
+
+// AccountResult is the EIP-1186 proof of an account and its storage, as returned by eth_getProof.
+type AccountResult struct {
+	Address      common.Address  `json:"address"`
+	AccountProof []hexutil.Bytes `json:"accountProof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []StorageResult `json:"storageProof"`
+}
+
+// StorageResult is the EIP-1186 proof of a storage slot of an account.
+type StorageResult struct {
+	Key   string          `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// GetProof returns the EIP-1186 proof of the account at the address in the state trie,
+// with the proofs of the storage slots in the storage trie of the account.
+// The storage trie may be nil if the account has no storage.
+func GetProof(state, storage *Trie, address common.Address, slots []common.Hash) (*AccountResult, error) {
+	if storage == nil {
+		storage = NewEmptyTrie(nil)
+	}
+
+	accountKey := crypto.Keccak256(address[:])
+
+	accountProof, err := state.Proof(accountKey)
+	if err != nil {
+		return nil, err
+	}
+
+	account := StateAccount{Balance: new(big.Int), Root: emptyRoot, CodeHash: emptyCodeHash[:]}
+	value, err := state.Get(accountKey)
+	absent := errors.Is(err, ErrNotFound)
+	switch {
+	case absent:
+	case err != nil:
+		return nil, err
+	default:
+		if err = rlp.DecodeBytes(value, &account); err != nil {
+			return nil, fmt.Errorf("account %s: %w", address, err)
+		}
+	}
+
+	if root := storage.Hash(); !bytes.Equal(root, account.Root) {
+		return nil, fmt.Errorf("%w: storage root %064x, got %064x", ErrAccountMismatch, account.Root, root)
+	}
+
+	result := &AccountResult{
+		Address:      address,
+		AccountProof: toHexProof(accountProof),
+		Balance:      (*hexutil.Big)(account.Balance),
+		CodeHash:     common.BytesToHash(account.CodeHash),
+		Nonce:        hexutil.Uint64(account.Nonce),
+		StorageHash:  common.BytesToHash(account.Root),
+		StorageProof: make([]StorageResult, 0, len(slots)),
+	}
+
+	if absent {
+		// Like go-ethereum, an absent account has zero hashes, and its slots are zero without proofs.
+		result.CodeHash, result.StorageHash = common.Hash{}, common.Hash{}
+
+		for _, slot := range slots {
+			result.StorageProof = append(result.StorageProof, StorageResult{
+				Key:   hexutil.Encode(slot[:]),
+				Value: new(hexutil.Big),
+				Proof: []hexutil.Bytes{},
+			})
+		}
+
+		return result, nil
+	}
+
+	for _, slot := range slots {
+		slotKey := crypto.Keccak256(slot[:])
+
+		proof, err := storage.Proof(slotKey)
+		if err != nil {
+			return nil, err
+		}
+
+		value := new(big.Int)
+		if rlpValue, err := storage.Get(slotKey); err == nil {
+			if value, err = decodeStorageValue(rlpValue); err != nil {
+				return nil, fmt.Errorf("storage slot %s: %w", slot, err)
+			}
+		} else if !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+
+		result.StorageProof = append(result.StorageProof, StorageResult{
+			Key:   hexutil.Encode(slot[:]),
+			Value: (*hexutil.Big)(value),
+			Proof: toHexProof(proof),
+		})
+	}
+
+	return result, nil
+}
+
+// VerifyAccountResult checks the EIP-1186 proof of an account against the state root,
+// and the proofs of its storage slots against the storage hash of the account.
+func VerifyAccountResult(stateRoot []byte, result *AccountResult) error {
+	value, err := VerifyProof(stateRoot, crypto.Keccak256(result.Address[:]), fromHexProof(result.AccountProof))
+
+	// If the account is absent, its fields must be empty, with zero hashes as returned by go-ethereum.
+	account := StateAccount{Balance: new(big.Int), Root: common.Hash{}.Bytes(), CodeHash: common.Hash{}.Bytes()}
+	absent := errors.Is(err, ErrNotFound)
+	switch {
+	case absent:
+	case err != nil:
+		return fmt.Errorf("account %s: %w", result.Address, err)
+	default:
+		if err = rlp.DecodeBytes(value, &account); err != nil {
+			return fmt.Errorf("account %s: %w", result.Address, err)
+		}
+	}
+
+	if uint64(result.Nonce) != account.Nonce ||
+		result.Balance == nil || result.Balance.ToInt().Cmp(account.Balance) != 0 ||
+		!bytes.Equal(result.CodeHash[:], account.CodeHash) ||
+		!bytes.Equal(result.StorageHash[:], account.Root) {
+		return fmt.Errorf("%w: account %s", ErrAccountMismatch, result.Address)
+	}
+
+	for _, storage := range result.StorageProof {
+		slot, err := parseStorageKey(storage.Key)
+		if err != nil {
+			return err
+		}
+
+		if absent { // An absent account has no storage root to check the proofs against.
+			if storage.Value == nil || storage.Value.ToInt().Sign() != 0 {
+				return fmt.Errorf("%w: storage slot %s", ErrStorageMismatch, storage.Key)
+			}
+
+			continue
+		}
+
+		rlpValue, err := VerifyProof(account.Root, crypto.Keccak256(slot[:]), fromHexProof(storage.Proof))
+
+		value := new(big.Int)
+		switch {
+		case errors.Is(err, ErrNotFound): // The slot is absent, its value must be zero.
+		case err != nil:
+			return fmt.Errorf("storage slot %s: %w", storage.Key, err)
+		default:
+			if value, err = decodeStorageValue(rlpValue); err != nil {
+				return fmt.Errorf("storage slot %s: %w", storage.Key, err)
+			}
+		}
+
+		if storage.Value == nil || storage.Value.ToInt().Cmp(value) != 0 {
+			return fmt.Errorf("%w: storage slot %s", ErrStorageMismatch, storage.Key)
+		}
+	}
+
+	return nil
+}
+
+// decodeStorageValue decodes a storage value, stored as the RLP encoding of its big-endian bytes.
+func decodeStorageValue(rlpValue []byte) (*big.Int, error) {
+	content, _, err := rlp.SplitString(rlpValue)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(content), nil
+}
+
+// parseStorageKey decodes a hex-encoded storage key, which may be shorter than 32 bytes.
+func parseStorageKey(key string) (common.Hash, error) {
+	key = strings.TrimPrefix(strings.TrimPrefix(key, "0x"), "0X")
+	if len(key)%2 == 1 {
+		key = "0" + key
+	}
+
+	raw, err := hex.DecodeString(key)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("storage key %s: %w", key, err)
+	} else if len(raw) > common.HashLength {
+		return common.Hash{}, fmt.Errorf("storage key %s: longer than %d bytes", key, common.HashLength)
+	}
+
+	return common.BytesToHash(raw), nil
+}
+
+func toHexProof(proof [][]byte) []hexutil.Bytes {
+	hexProof := make([]hexutil.Bytes, len(proof))
+	for i, rlpEnc := range proof {
+		hexProof[i] = rlpEnc
+	}
+
+	return hexProof
+}
+
+func fromHexProof(hexProof []hexutil.Bytes) [][]byte {
+	proof := make([][]byte, len(hexProof))
+	for i, rlpEnc := range hexProof {
+		proof[i] = rlpEnc
+	}
+
+	return proof
+}
@@ -0,0 +1,256 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/holiman/uint256"
+
+	"go.0xjac.com/tfmpt/crypto"
+)
+
+var (
+	accountWithStorage = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	accountNoStorage   = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	accountMissing     = common.HexToAddress("0x00000000000000000000000000000000000000cc")
+
+	slots = map[common.Hash]*big.Int{
+		common.HexToHash("0x01"): big.NewInt(1),
+		common.HexToHash("0x02"): big.NewInt(0x1234),
+		common.HexToHash("0x03"): new(big.Int).Lsh(big.NewInt(1), 200),
+	}
+	slotMissing = common.HexToHash("0x04")
+)
+
+func TestGetProof(t *testing.T) {
+	state, storage, ethState, ethStorage := stateFixture(t)
+	root := state.Hash()
+
+	for _, address := range []common.Address{accountWithStorage, accountNoStorage, accountMissing} {
+		t.Run(fmt.Sprintf("GetProof[%s]", address), func(t *testing.T) {
+			var (
+				accountStorage, ethAccountStorage = storage, ethStorage
+				keys                              = []common.Hash{slotMissing}
+			)
+
+			if address != accountWithStorage {
+				accountStorage, ethAccountStorage = nil, trie.NewEmpty(nil)
+			} else {
+				for slot := range slots {
+					keys = append(keys, slot)
+				}
+			}
+
+			result, err := GetProof(state, accountStorage, address, keys)
+			if err != nil {
+				t.Fatalf("Expected a proof for account=%s, got err=%s", address, err)
+			}
+
+			assertEthProof(t, ethState, crypto.Keccak256(address[:]), result.AccountProof)
+
+			for i, storageResult := range result.StorageProof {
+				assertEthProof(t, ethAccountStorage, crypto.Keccak256(keys[i][:]), storageResult.Proof)
+
+				if expected := slots[keys[i]]; expected != nil && storageResult.Value.ToInt().Cmp(expected) != 0 {
+					t.Errorf("Expected slot=%s to be %s, got val=%s", keys[i], expected, storageResult.Value)
+				}
+			}
+
+			if address == accountMissing && (result.CodeHash != common.Hash{} || result.StorageHash != common.Hash{}) {
+				t.Errorf("Expected zero hashes for account=%s, got codeHash=%s storageHash=%s",
+					address, result.CodeHash, result.StorageHash)
+			}
+
+			if err = VerifyAccountResult(root, result); err != nil {
+				t.Errorf("Expected proof for account=%s to be valid, got err=%s", address, err)
+			}
+
+			// The proof must still be valid once encoded to and decoded from its JSON representation.
+			var decoded AccountResult
+
+			if raw, err := json.Marshal(result); err != nil {
+				t.Errorf("Expected proof for account=%s to be encoded, got err=%s", address, err)
+			} else if err = json.Unmarshal(raw, &decoded); err != nil {
+				t.Errorf("Expected proof for account=%s to be decoded, got err=%s", address, err)
+			} else if err = VerifyAccountResult(root, &decoded); err != nil {
+				t.Errorf("Expected decoded proof for account=%s to be valid, got err=%s", address, err)
+			}
+		})
+	}
+}
+
+func TestVerifyAccountResultInvalid(t *testing.T) {
+	state, storage, _, _ := stateFixture(t)
+	root := state.Hash()
+
+	resultFixture := func(t *testing.T) *AccountResult {
+		t.Helper()
+
+		result, err := GetProof(state, storage, accountWithStorage, []common.Hash{common.HexToHash("0x02")})
+		if err != nil {
+			t.Fatalf("Expected a proof for account=%s, got err=%s", accountWithStorage, err)
+		}
+
+		return result
+	}
+
+	t.Run("VerifyAccountResult[balance]", func(t *testing.T) {
+		result := resultFixture(t)
+		result.Balance = (*hexutil.Big)(big.NewInt(1_000_000))
+
+		if err := VerifyAccountResult(root, result); !errors.Is(err, ErrAccountMismatch) {
+			t.Errorf("Expected account mismatch, got err=%s", err)
+		}
+	})
+
+	t.Run("VerifyAccountResult[storage_value]", func(t *testing.T) {
+		result := resultFixture(t)
+		result.StorageProof[0].Value = (*hexutil.Big)(big.NewInt(0x4321))
+
+		if err := VerifyAccountResult(root, result); !errors.Is(err, ErrStorageMismatch) {
+			t.Errorf("Expected storage mismatch, got err=%s", err)
+		}
+	})
+
+	t.Run("VerifyAccountResult[short_key]", func(t *testing.T) {
+		result := resultFixture(t)
+		result.StorageProof[0].Key = "0x2"
+
+		if err := VerifyAccountResult(root, result); err != nil {
+			t.Errorf("Expected proof with short key to be valid, got err=%s", err)
+		}
+	})
+
+	t.Run("VerifyAccountResult[account_proof]", func(t *testing.T) {
+		result := resultFixture(t)
+		result.AccountProof = result.AccountProof[1:]
+
+		if err := VerifyAccountResult(root, result); !errors.Is(err, ErrInvalidProof) {
+			t.Errorf("Expected invalid proof, got err=%s", err)
+		}
+	})
+}
+
+func TestVerifyAccountResultMissing(t *testing.T) {
+	_, _, ethState, _ := stateFixture(t)
+
+	proof := newMockEthProofDB(0)
+	if err := ethState.Prove(crypto.Keccak256(accountMissing[:]), proof); err != nil {
+		t.Fatalf("Expected a proof for account=%s, got err=%s", accountMissing, err)
+	}
+
+	var accountProof []string // The order of the parts does not matter to verify the proof.
+	for _, part := range proof {
+		accountProof = append(accountProof, hexutil.Encode(part))
+	}
+
+	rawProof, err := json.Marshal(accountProof)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// As returned by eth_getProof from go-ethereum for an absent account.
+	raw := fmt.Sprintf(`{
+		"address": "%s",
+		"accountProof": %s,
+		"balance": "0x0",
+		"codeHash": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"nonce": "0x0",
+		"storageHash": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"storageProof": [{"key": "0x4", "value": "0x0", "proof": []}]
+	}`, accountMissing, rawProof)
+
+	var result AccountResult
+	if err = json.Unmarshal([]byte(raw), &result); err != nil {
+		t.Fatalf("Expected proof to be decoded, got err=%s", err)
+	}
+
+	root := ethState.Hash().Bytes()
+	if err = VerifyAccountResult(root, &result); err != nil {
+		t.Errorf("Expected proof for account=%s to be valid, got err=%s", accountMissing, err)
+	}
+
+	result.StorageProof[0].Value = (*hexutil.Big)(big.NewInt(1))
+	if err = VerifyAccountResult(root, &result); !errors.Is(err, ErrStorageMismatch) {
+		t.Errorf("Expected storage mismatch, got err=%s", err)
+	}
+
+	result.StorageProof[0].Value = new(hexutil.Big)
+	result.CodeHash = emptyCodeHash
+	if err = VerifyAccountResult(root, &result); !errors.Is(err, ErrAccountMismatch) {
+		t.Errorf("Expected account mismatch, got err=%s", err)
+	}
+}
+
+func assertEthProof(t *testing.T, ethMPT *trie.Trie, key []byte, proof []hexutil.Bytes) {
+	t.Helper()
+
+	expected := newMockEthProofDB(len(proof))
+	if err := ethMPT.Prove(key, expected); err != nil {
+		t.Fatalf("Expected a proof for key=%x, got err=%s", key, err)
+	}
+
+	if len(proof) != len(expected) {
+		t.Errorf("Expected proof length=%d, got length=%d", len(expected), len(proof))
+	}
+
+	for _, part := range proof {
+		if rlpEnc, ok := expected[string(crypto.Keccak256(part))]; !ok || !bytes.Equal(part, rlpEnc) {
+			t.Errorf("Bad proof part=%x", part)
+		}
+	}
+}
+
+// stateFixture returns a state trie and the storage trie of one of its accounts,
+// with their equivalent from the official go-ethereum implementation.
+func stateFixture(t *testing.T) (*Trie, *Trie, *trie.Trie, *trie.Trie) {
+	t.Helper()
+
+	storage, ethStorage := NewEmptyTrie(nil), trie.NewEmpty(nil)
+	for slot, value := range slots {
+		rlpValue, err := rlp.EncodeToBytes(value.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		storage.Put(crypto.Keccak256(slot[:]), rlpValue)
+		ethStorage.MustUpdate(crypto.Keccak256(slot[:]), rlpValue)
+	}
+
+	state, ethState := NewEmptyTrie(nil), trie.NewEmpty(nil)
+	for address, account := range map[common.Address]*types.StateAccount{
+		accountWithStorage: {
+			Nonce:    7,
+			Balance:  uint256.NewInt(1_000_000_000),
+			Root:     ethStorage.Hash(),
+			CodeHash: crypto.Keccak256([]byte("code")),
+		},
+		accountNoStorage: {
+			Nonce:    1,
+			Balance:  uint256.NewInt(42),
+			Root:     types.EmptyRootHash,
+			CodeHash: types.EmptyCodeHash[:],
+		},
+	} {
+		rlpAccount, err := rlp.EncodeToBytes(account)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		state.Put(crypto.Keccak256(address[:]), rlpAccount)
+		ethState.MustUpdate(crypto.Keccak256(address[:]), rlpAccount)
+	}
+
+	return state, storage, ethState, ethStorage
+}
@@ -17,6 +17,30 @@ func ExampleToHex() {
 	// 10
 }
 
+func ExampleFromHex() {
+	fmt.Printf("%s\n", encoding.FromHex([]byte{0x06, 0x0b, 0x06, 0x05, 0x07, 0x09, 0x10}))
+	fmt.Printf("%s\n", encoding.FromHex([]byte{0x06, 0x0b, 0x06, 0x05, 0x07, 0x09}))
+	fmt.Printf("%q\n", encoding.FromHex([]byte{0x10}))
+	// Output:
+	// key
+	// key
+	// ""
+}
+
+func ExampleCompareHex() {
+	fmt.Println(encoding.CompareHex(encoding.ToHex([]byte("do")), encoding.ToHex([]byte("dog"))))
+	fmt.Println(encoding.CompareHex(encoding.ToHex([]byte("dog")), encoding.ToHex([]byte("do"))))
+	fmt.Println(encoding.CompareHex(encoding.ToHex([]byte("dog")), encoding.ToHex([]byte("dog"))))
+	fmt.Println(encoding.CompareHex(encoding.ToHex([]byte("doge")), encoding.ToHex([]byte("horse"))))
+	fmt.Println(encoding.CompareHex([]byte{0x06, 0x04}, encoding.ToHex([]byte("do"))))
+	// Output:
+	// -1
+	// 1
+	// 0
+	// -1
+	// -1
+}
+
 func ExampleCompact() {
 	key := []byte{0x06, 0x0b, 0x06, 0x05, 0x07, 0x09, 0x10}
 	fmt.Printf("%s\n", encoding.Compact(key))
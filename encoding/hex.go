@@ -25,6 +25,47 @@ func ToHex(key []byte) []byte {
 	return nibbles
 }
 
+// FromHex decodes a byte sequence of hex-encoded nibbles, with or without terminator, into a key.
+func FromHex(hex []byte) []byte {
+	if HexKeyHasTerm(hex) {
+		hex = hex[:len(hex)-1]
+	}
+
+	key := make([]byte, len(hex)/2)
+	for bi, ni := 0, 0; ni+1 < len(hex); bi, ni = bi+1, ni+2 {
+		key[bi] = hex[ni]<<nibbleSize | hex[ni+1]
+	}
+
+	return key
+}
+
+// CompareHex compares two hex-encoded keys in the lexicographic order of the keys they encode.
+// The terminator is ordered before any other nibble, so that a key comes before the longer keys
+// it is a prefix of. The result is 0 if a == b, -1 if a < b, and +1 if a > b.
+func CompareHex(a, b []byte) int {
+	for i := 0; i < min(len(a), len(b)); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+
+		// Shift the nibbles by one, wrapping the terminator around to 0.
+		if (a[i]+1)%(terminator+1) < (b[i]+1)%(terminator+1) {
+			return -1
+		}
+
+		return 1
+	}
+
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	default:
+		return 0
+	}
+}
+
 // CommonPrefixLen returns the length of the common prefix between to paths.
 func CommonPrefixLen[T comparable](pathA, pathB []T) int {
 	var i int
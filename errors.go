@@ -0,0 +1,47 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"fmt"
+
+	"go.0xjac.com/tfmpt/node"
+)
+
+// MissingNodeError is returned when a hashed node cannot be loaded from the store.
+type MissingNodeError struct {
+	Path []byte      // Path is the hex-encoded path of the node from the root.
+	Hash node.Hashed // Hash is the expected hash of the node.
+	Err  error       // Err is the underlying store error.
+}
+
+func (e *MissingNodeError) Error() string {
+	return fmt.Sprintf("missing node %064x at path [% x]: %v", []byte(e.Hash), e.Path, e.Err)
+}
+
+func (e *MissingNodeError) Unwrap() error { return e.Err }
+
+// DecodeError is returned when a node loaded from the store cannot be decoded.
+type DecodeError struct {
+	Path []byte      // Path is the hex-encoded path of the node from the root.
+	Hash node.Hashed // Hash is the expected hash of the node.
+	Err  error       // Err is the underlying RLP decoding error.
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decode error for node %064x at path [% x]: %v", []byte(e.Hash), e.Path, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// CorruptNodeError is returned when a node loaded from the store does not match its expected hash.
+type CorruptNodeError struct {
+	Path     []byte      // Path is the hex-encoded path of the node from the root.
+	Expected node.Hashed // Expected is the hash referencing the node in its parent.
+	Actual   node.Hashed // Actual is the hash of the node in the store.
+}
+
+func (e *CorruptNodeError) Error() string {
+	return fmt.Sprintf("corrupt node at path [% x]: expected hash %064x, got %064x",
+		e.Path, []byte(e.Expected), []byte(e.Actual))
+}
@@ -0,0 +1,176 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"go.0xjac.com/tfmpt/crypto"
+	"go.0xjac.com/tfmpt/store"
+)
+
+const exportVersion = 1
+
+var (
+	ErrInvalidExport = errors.New("invalid export")
+
+	// exportMagic starts every export, followed by the version of the format.
+	exportMagic = []byte("tfmpt-export")
+
+	// exportEnd is the empty RLP list marking the end of the [key, value] pairs of an export.
+	exportEnd = []byte{0xc0}
+)
+
+// exportPair is a [key, value] pair of an export.
+type exportPair struct {
+	Key   []byte
+	Value []byte
+}
+
+// Export writes the trie committed to the store with the given root and options to the writer,
+// independently of the store and of the layout of the nodes. The stream is made of:
+//
+//   - the magic bytes "tfmpt-export" and the version of the format as a single byte,
+//   - the RLP encoded root hash,
+//   - the RLP encoded [key, value] lists of all the pairs of the trie, in order,
+//   - an empty RLP list marking the end of the pairs,
+//   - the Keccak256 checksum of all the previous bytes.
+//
+// The nodes are checked against their hash as they are loaded, so that a wrong root or a corrupt store
+// fails the export rather than the import. If it fails, the writer holds a partial export, which Import rejects.
+func Export(w io.Writer, db store.DB, root []byte, opts ...Option) error {
+	checksum := crypto.NewKeccakState()
+	out := io.MultiWriter(w, checksum)
+
+	if _, err := out.Write(append(append([]byte{}, exportMagic...), exportVersion)); err != nil {
+		return err
+	}
+
+	if err := rlp.Encode(out, root); err != nil {
+		return err
+	}
+
+	it := LoadTrie(db, root, append(opts[:len(opts):len(opts)], WithHashCheck())...).Iterator(nil)
+	for it.Next() {
+		if err := rlp.Encode(out, &exportPair{Key: it.Key(), Value: it.Value()}); err != nil {
+			return err
+		}
+	}
+
+	if it.Err() != nil {
+		return it.Err()
+	}
+
+	if _, err := out.Write(exportEnd); err != nil {
+		return err
+	}
+
+	_, err := w.Write(checksum.Sum(nil))
+
+	return err
+}
+
+// Import rebuilds the trie of an export written by Export, and commits it to the store with the given options.
+// The export is rejected if its checksum or the root hash of the rebuilt trie does not match.
+//
+// The root hash can only be checked once every pair is inserted, and nothing is written to the store
+// before, so that a rejected export leaves the store untouched. The whole trie is thus held in memory
+// until it is committed, which bounds the size of the tries which can be imported.
+func Import(r io.Reader, db store.DB, opts ...Option) (*Trie, error) {
+	in := &hashingReader{r: bufio.NewReader(r), checksum: crypto.NewKeccakState()}
+
+	header := make([]byte, len(exportMagic)+1)
+	if _, err := io.ReadFull(in, header); err != nil {
+		return nil, fmt.Errorf("%w: header: %w", ErrInvalidExport, err)
+	} else if !bytes.Equal(header[:len(exportMagic)], exportMagic) {
+		return nil, fmt.Errorf("%w: bad magic %x", ErrInvalidExport, header[:len(exportMagic)])
+	} else if version := header[len(exportMagic)]; version != exportVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidExport, version)
+	}
+
+	stream := rlp.NewStream(in, 0)
+
+	root, err := stream.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("%w: root: %w", ErrInvalidExport, err)
+	}
+
+	mpt := NewEmptyTrie(db, opts...)
+
+	var previous []byte
+	for i := 0; ; i++ {
+		if size, err := stream.List(); err != nil {
+			return nil, fmt.Errorf("%w: pair %d: %w", ErrInvalidExport, i, err)
+		} else if size == 0 {
+			break // End of the pairs.
+		}
+
+		var pair exportPair
+		if pair.Key, err = stream.Bytes(); err != nil {
+			return nil, fmt.Errorf("%w: pair %d: %w", ErrInvalidExport, i, err)
+		} else if pair.Value, err = stream.Bytes(); err != nil {
+			return nil, fmt.Errorf("%w: pair %d: %w", ErrInvalidExport, i, err)
+		} else if err = stream.ListEnd(); err != nil {
+			return nil, fmt.Errorf("%w: pair %d: %w", ErrInvalidExport, i, err)
+		}
+
+		if i > 0 && bytes.Compare(previous, pair.Key) >= 0 {
+			return nil, fmt.Errorf("%w: keys are not sorted at key %x", ErrInvalidExport, pair.Key)
+		} else if len(pair.Value) == 0 {
+			return nil, fmt.Errorf("%w: empty value for key %x", ErrInvalidExport, pair.Key)
+		}
+
+		if err = mpt.Update(pair.Key, pair.Value); err != nil {
+			return nil, err
+		}
+
+		previous = pair.Key
+	}
+
+	// The checksum follows the end of the pairs and is not part of it.
+	expected, checksum := make([]byte, 32), in.checksum.Sum(nil)
+	if _, err = io.ReadFull(in.r, expected); err != nil {
+		return nil, fmt.Errorf("%w: checksum: %w", ErrInvalidExport, err)
+	} else if !bytes.Equal(checksum, expected) {
+		return nil, fmt.Errorf("%w: checksum mismatch %x, got %x", ErrInvalidExport, expected, checksum)
+	}
+
+	if hash := mpt.Hash(); !bytes.Equal(hash, root) {
+		return nil, fmt.Errorf("%w: root mismatch %064x, got %064x", ErrInvalidExport, root, hash)
+	}
+
+	if _, err = mpt.Commit(); err != nil {
+		return nil, err
+	}
+
+	return mpt, nil
+}
+
+// hashingReader hashes all the bytes read. It implements io.ByteReader
+// so that the RLP stream does not buffer, and thus hash, the bytes after the ones it decodes.
+type hashingReader struct {
+	r        *bufio.Reader
+	checksum crypto.KeccakState
+}
+
+func (h *hashingReader) Read(p []byte) (int, error) {
+	n, err := h.r.Read(p)
+	h.checksum.Write(p[:n])
+
+	return n, err
+}
+
+func (h *hashingReader) ReadByte() (byte, error) {
+	b, err := h.r.ReadByte()
+	if err == nil {
+		h.checksum.Write([]byte{b})
+	}
+
+	return b, err
+}
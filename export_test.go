@@ -0,0 +1,156 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"go.0xjac.com/tfmpt/store"
+)
+
+func TestExportImport(t *testing.T) {
+	t.Run("Import[round_trip]", func(t *testing.T) {
+		t.Parallel()
+
+		db, root, pairs := committedTrieFixture(t, 300)
+
+		var export bytes.Buffer
+		if err := Export(&export, db, root); err != nil {
+			t.Fatalf("Expected trie to be exported, got err=%s", err)
+		}
+
+		// The nodes are laid out differently in the destination store.
+		dst := store.NewMemoryDB()
+		opts := []Option{WithScheme(HashScheme), WithOwner([]byte("owner"))}
+
+		mpt, err := Import(&export, dst, opts...)
+		if err != nil {
+			t.Fatalf("Expected trie to be imported, got err=%s", err)
+		} else if hash := mpt.Hash(); !bytes.Equal(hash, root) {
+			t.Fatalf("Expected root=%064x, got root=%064x", root, hash)
+		}
+
+		loaded := LoadTrie(dst, root, opts...)
+		for _, p := range pairs {
+			val, err := loaded.Get(p.key)
+			assertPresent(t, p.key, val, p.val, err)
+		}
+
+		if report, err := Verify(dst, root, opts...); err != nil || !report.OK() {
+			t.Errorf("Expected a sound trie, got report=%+v err=%v", report, err)
+		}
+	})
+
+	t.Run("Import[empty]", func(t *testing.T) {
+		t.Parallel()
+
+		var export bytes.Buffer
+		if err := Export(&export, store.NewMemoryDB(), emptyRoot); err != nil {
+			t.Fatalf("Expected trie to be exported, got err=%s", err)
+		}
+
+		mpt, err := Import(&export, store.NewMemoryDB())
+		if err != nil {
+			t.Fatalf("Expected trie to be imported, got err=%s", err)
+		} else if hash := mpt.Hash(); !bytes.Equal(hash, emptyRoot) {
+			t.Errorf("Expected root=%064x, got root=%064x", emptyRoot, hash)
+		}
+	})
+
+	t.Run("Export[wrong_root]", func(t *testing.T) {
+		t.Parallel()
+
+		db, root, _ := committedTrieFixture(t, 50)
+
+		// With the path scheme, the root node is found even if its hash does not match.
+		wrong := append([]byte{}, root...)
+		wrong[0] ^= 0xff
+
+		var corrupt *CorruptNodeError
+		if err := Export(io.Discard, db, wrong); !errors.As(err, &corrupt) {
+			t.Errorf("Expected a corrupt node error, got err=%v", err)
+		}
+	})
+
+	t.Run("Import[invalid]", func(t *testing.T) {
+		t.Parallel()
+
+		db, root, pairs := committedTrieFixture(t, 50)
+
+		var buf bytes.Buffer
+		if err := Export(&buf, db, root); err != nil {
+			t.Fatalf("Expected trie to be exported, got err=%s", err)
+		}
+
+		export := buf.Bytes()
+		checksumAt, rootAt := len(export)-32, len(exportMagic)+2
+
+		for name, corrupt := range map[string]func([]byte){
+			"magic":     func(b []byte) { b[0] ^= 0xff },
+			"version":   func(b []byte) { b[len(exportMagic)] = exportVersion + 1 },
+			"root":      func(b []byte) { b[rootAt] ^= 0xff },
+			"checksum":  func(b []byte) { b[checksumAt] ^= 0xff },
+			"value":     func(b []byte) { b[checksumAt-2] ^= 0xff },
+			"truncated": nil,
+		} {
+			corrupted := append([]byte{}, export...)
+			if corrupt != nil {
+				corrupt(corrupted)
+			} else {
+				corrupted = corrupted[:checksumAt-len(pairs[0].val)]
+			}
+
+			dst := store.NewMemoryDB()
+			if _, err := Import(bytes.NewReader(corrupted), dst); !errors.Is(err, ErrInvalidExport) {
+				t.Errorf("Expected err=%s for corrupted %s, got err=%v", ErrInvalidExport, name, err)
+			} else if dst.Len() != 0 {
+				t.Errorf("Expected nothing to be imported for corrupted %s, got %d keys", name, dst.Len())
+			}
+		}
+	})
+
+	t.Run("Import[unsorted]", func(t *testing.T) {
+		t.Parallel()
+
+		src, dst := NewEmptyTrie(store.NewMemoryDB()), NewEmptyTrie(store.NewMemoryDB())
+		src.Put([]byte("dog"), []byte("puppy"))
+		src.Put([]byte("horse"), []byte("stallion"))
+
+		root, err := src.Commit()
+		if err != nil {
+			t.Fatalf("Expected trie to be committed, got err=%s", err)
+		}
+
+		var export bytes.Buffer
+		if err = Export(&export, src.db, root); err != nil {
+			t.Fatalf("Expected trie to be exported, got err=%s", err)
+		}
+
+		dog, err := rlp.EncodeToBytes(&exportPair{Key: []byte("dog"), Value: []byte("puppy")})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		horse, err := rlp.EncodeToBytes(&exportPair{Key: []byte("horse"), Value: []byte("stallion")})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Swap the two pairs.
+		raw := export.Bytes()
+		pairs := append(append([]byte{}, dog...), horse...)
+		if !bytes.Contains(raw, pairs) {
+			t.Fatalf("Expected pairs=%x in export=%x", pairs, raw)
+		}
+
+		swapped := bytes.Replace(raw, pairs, append(append([]byte{}, horse...), dog...), 1)
+		if _, err = Import(bytes.NewReader(swapped), dst.db); !errors.Is(err, ErrInvalidExport) {
+			t.Errorf("Expected err=%s, got err=%v", ErrInvalidExport, err)
+		}
+	})
+}
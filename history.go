@@ -0,0 +1,190 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"go.0xjac.com/tfmpt/node"
+	"go.0xjac.com/tfmpt/store"
+)
+
+var (
+	ErrNoHistory = errors.New("root is not in the history")
+
+	// historyPrefix prefixes the keys of the state history in the store.
+	// It cannot collide with the path of a node, made of nibbles and terminators only.
+	historyPrefix = []byte("tfmpt-history-")
+)
+
+// historyMeta is the latest root committed and the range of ids of the history entries kept in the store.
+// The history is empty when Last < First.
+type historyMeta struct {
+	Root  []byte
+	First uint64
+	Last  uint64
+}
+
+// historyEntry is the reverse diff of a commit, from its parent root to its root.
+// Values are the previous values of the keys in the store, empty if the key was absent.
+type historyEntry struct {
+	Parent []byte
+	Root   []byte
+	Keys   [][]byte
+	Values [][]byte
+}
+
+// recordHistory adds to the writes of a commit to the given root their reverse diff,
+// and the removal of the oldest entries beyond the number of versions to keep.
+func (t *Trie) recordHistory(root node.Hashed, writes storeWrites) (storeWrites, error) {
+	meta, err := t.historyMeta()
+	if err != nil {
+		return nil, err
+	}
+
+	entry := historyEntry{Parent: meta.Root, Root: root}
+	seen := make(map[string]struct{}, len(writes))
+
+	for _, write := range writes {
+		if _, ok := seen[string(write.key)]; ok {
+			continue // Only the value before the commit is kept.
+		}
+
+		seen[string(write.key)] = struct{}{}
+
+		previous, err := t.db.Get(write.key)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return nil, err
+		}
+
+		entry.Keys, entry.Values = append(entry.Keys, write.key), append(entry.Values, previous)
+	}
+
+	rlpEntry, err := rlp.EncodeToBytes(&entry)
+	if err != nil {
+		return nil, err
+	}
+
+	meta.Root, meta.Last = root, meta.Last+1
+	writes = append(writes, storeWrite{key: t.historyKey(meta.Last), value: rlpEntry})
+
+	for ; meta.Last-meta.First+1 > t.history; meta.First++ {
+		writes = append(writes, storeWrite{key: t.historyKey(meta.First)})
+	}
+
+	rlpMeta, err := rlp.EncodeToBytes(&meta)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(writes, storeWrite{key: t.historyKey(0), value: rlpMeta}), nil
+}
+
+// Rollback rewinds the trie and its nodes in the store to an earlier root recorded in the history.
+// The versions after the root are removed from the history and uncommitted changes are discarded.
+// All the commits to the trie must have been made with the history enabled.
+func (t *Trie) Rollback(root []byte) error {
+	if t.readOnly {
+		return ErrReadOnly
+	} else if t.db == nil {
+		return ErrNoDB
+	} else if t.scheme != PathScheme {
+		return fmt.Errorf("%w: history requires the %s scheme", ErrScheme, PathScheme)
+	}
+
+	meta, err := t.historyMeta()
+	if err != nil {
+		return err
+	}
+
+	// Find the entries to revert, from the latest, before changing anything.
+	var entries []*historyEntry
+	for id, parent := meta.Last, meta.Root; !bytes.Equal(parent, root); id-- {
+		if id < meta.First {
+			return fmt.Errorf("%w: %064x", ErrNoHistory, root)
+		}
+
+		entry, err := t.historyEntry(id)
+		if err != nil {
+			return err
+		}
+
+		entries, parent = append(entries, entry), entry.Parent
+	}
+
+	// Revert the entries from the latest, so the oldest previous value of a key is written last.
+	var writes storeWrites
+	for _, entry := range entries {
+		for i, key := range entry.Keys {
+			write := storeWrite{key: key}
+			if len(entry.Values[i]) > 0 {
+				write.value = entry.Values[i]
+			}
+
+			writes = append(writes, write)
+		}
+
+		writes = append(writes, storeWrite{key: t.historyKey(meta.Last)})
+		meta.Root, meta.Last = entry.Parent, meta.Last-1
+	}
+
+	if len(entries) > 0 {
+		rlpMeta, err := rlp.EncodeToBytes(&meta)
+		if err != nil {
+			return err
+		}
+
+		if err = append(writes, storeWrite{key: t.historyKey(0), value: rlpMeta}).apply(t.db); err != nil {
+			return err
+		}
+	}
+
+	t.root = nil
+	if !bytes.Equal(root, emptyRoot) {
+		t.root = node.Hashed(root)
+	}
+
+	t.deleted = make(map[string]struct{})
+
+	return nil
+}
+
+func (t *Trie) historyMeta() (historyMeta, error) {
+	meta := historyMeta{Root: emptyRoot, First: 1}
+
+	raw, err := t.db.Get(t.historyKey(0))
+	switch {
+	case errors.Is(err, store.ErrNotFound) || (err == nil && raw == nil):
+		return meta, nil
+	case err != nil:
+		return meta, err
+	}
+
+	return meta, rlp.DecodeBytes(raw, &meta)
+}
+
+func (t *Trie) historyEntry(id uint64) (*historyEntry, error) {
+	raw, err := t.db.Get(t.historyKey(id))
+	if err != nil {
+		return nil, fmt.Errorf("history entry %d: %w", id, err)
+	}
+
+	entry := new(historyEntry)
+	if err = rlp.DecodeBytes(raw, entry); err != nil {
+		return nil, fmt.Errorf("history entry %d: %w", id, err)
+	}
+
+	return entry, nil
+}
+
+// historyKey returns the key in the store of the history entry with the given id,
+// or of the history metadata for the id 0.
+func (t *Trie) historyKey(id uint64) []byte {
+	key := append(append(make([]byte, 0, len(historyPrefix)+len(t.owner)+8), historyPrefix...), t.owner...)
+	return binary.BigEndian.AppendUint64(key, id)
+}
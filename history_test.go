@@ -0,0 +1,136 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestTrieRollback(t *testing.T) {
+	db, cleanup := storageFixture(t)
+	defer cleanup()
+
+	r := rand.New(rand.NewSource(6))
+	mpt := NewEmptyTrie(db, WithHistory(3))
+
+	// Commit 5 versions, each adding, updating and deleting keys.
+	var (
+		roots    [][]byte
+		versions []map[string][]byte
+		current  = make(map[string][]byte)
+	)
+
+	for v := 0; v < 5; v++ {
+		for key := range current {
+			switch r.Intn(4) {
+			case 0:
+				current[key] = []byte(fmt.Sprintf("<val_%d>", v))
+				mpt.Put([]byte(key), current[key])
+			case 1:
+				delete(current, key)
+				if err := mpt.Del([]byte(key)); err != nil {
+					t.Fatalf("Expected key=%x to be deleted, got err=%s", key, err)
+				}
+			}
+		}
+
+		for i := 0; i < 50; i++ {
+			key, val := make([]byte, 1+r.Intn(6)), make([]byte, 1+r.Intn(40))
+			r.Read(key)
+			r.Read(val)
+
+			current[string(key)] = val
+			mpt.Put(key, val)
+		}
+
+		root, err := mpt.Commit()
+		if err != nil {
+			t.Fatalf("Expected version=%d to be committed, got err=%s", v, err)
+		}
+
+		version := make(map[string][]byte, len(current))
+		for key, val := range current {
+			version[key] = val
+		}
+
+		roots, versions = append(roots, root), append(versions, version)
+	}
+
+	// Only the last 3 versions are kept, the oldest root is out of the history.
+	if err := mpt.Rollback(roots[0]); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("Expected rollback to root=%x to fail, got err=%s", roots[0], err)
+	}
+
+	for _, v := range []int{4, 2, 1} {
+		if err := mpt.Rollback(roots[v]); err != nil {
+			t.Fatalf("Expected rollback to version=%d, got err=%s", v, err)
+		}
+
+		assertVersion(t, mpt, roots[v], versions[v])
+		assertVersion(t, LoadTrie(db, roots[v], WithHistory(3)), roots[v], versions[v])
+	}
+
+	// The rolled back versions are removed from the history.
+	if err := mpt.Rollback(roots[3]); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("Expected rollback to root=%x to fail, got err=%s", roots[3], err)
+	}
+
+	// New versions can be committed after a rollback.
+	mpt.Put([]byte("<new_key>"), []byte("<new_val>"))
+	if _, err := mpt.Commit(); err != nil {
+		t.Fatalf("Expected trie to be committed, got err=%s", err)
+	}
+
+	if err := mpt.Rollback(roots[1]); err != nil {
+		t.Fatalf("Expected rollback to version=1, got err=%s", err)
+	}
+
+	assertVersion(t, mpt, roots[1], versions[1])
+}
+
+func TestTrieHistoryScheme(t *testing.T) {
+	db, cleanup := storageFixture(t)
+	defer cleanup()
+
+	mpt := NewEmptyTrie(db, WithScheme(HashScheme), WithHistory(1))
+	mpt.Put([]byte("dog"), []byte("puppy"))
+
+	if _, err := mpt.Commit(); !errors.Is(err, ErrScheme) {
+		t.Errorf("Expected commit to fail with err=%s, got err=%s", ErrScheme, err)
+	}
+
+	if err := mpt.Rollback(emptyRoot); !errors.Is(err, ErrScheme) {
+		t.Errorf("Expected rollback to fail with err=%s, got err=%s", ErrScheme, err)
+	}
+}
+
+// assertVersion checks the trie has the given root and holds exactly the [key, value] pairs.
+func assertVersion(t *testing.T, mpt *Trie, root []byte, pairs map[string][]byte) {
+	t.Helper()
+
+	it := mpt.Iterator(nil)
+	count := 0
+
+	for ; it.Next(); count++ {
+		if expected, ok := pairs[string(it.Key())]; !ok {
+			t.Errorf("Unexpected key=%x", it.Key())
+		} else {
+			assertPresent(t, it.Key(), it.Value(), expected, nil)
+		}
+	}
+
+	if it.Err() != nil {
+		t.Fatalf("Expected iteration to succeed, got err=%s", it.Err())
+	}
+
+	if count != len(pairs) {
+		t.Errorf("Expected %d pairs, got %d pairs", len(pairs), count)
+	}
+
+	if hash := mpt.Hash(); string(hash) != string(root) {
+		t.Errorf("Expected root=%064x, got root=%064x", root, hash)
+	}
+}
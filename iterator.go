@@ -0,0 +1,178 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"fmt"
+
+	"go.0xjac.com/tfmpt/encoding"
+	"go.0xjac.com/tfmpt/node"
+)
+
+// branchOrder is the order in which the children of a branch are visited to follow the
+// lexicographic order of the keys: the value at the branch comes before its children.
+var branchOrder = [node.BranchSize]byte{node.BranchValue, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// reverseBranchOrder is the order in which the children of a branch are visited to follow the
+// reverse lexicographic order of the keys: the value at the branch comes after its children.
+var reverseBranchOrder = [node.BranchSize]byte{15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1, 0, node.BranchValue}
+
+// Iterator iterates over the [key, value] pairs of a trie in the lexicographic order of the keys,
+// or in the reverse order.
+// Hashed nodes are only loaded from the store when the iterator reaches them.
+// The trie must not be modified while it is iterated over.
+type Iterator struct {
+	trie    *Trie
+	start   []byte // start is the hex-encoded key from which to iterate.
+	end     []byte // end is the hex-encoded key, without terminator, before which to stop, if any.
+	prefix  []byte // prefix is the hex-encoded prefix, without terminator, of all the keys, if any.
+	stack   []*iteratorFrame
+	reverse bool
+	key     []byte
+	value   []byte
+	err     error
+}
+
+// iteratorFrame is a node of the trie left to iterate over, at a hex-encoded path from the root.
+type iteratorFrame struct {
+	node  node.Node
+	path  []byte
+	index int // index is the position in branchOrder of the next child of a branch to visit.
+}
+
+// Iterator returns an iterator over the [key, value] pairs of the trie
+// from the start key (included), or from the first key if start is nil.
+func (t *Trie) Iterator(start []byte) *Iterator {
+	return t.newIterator(encoding.ToHex(start), nil, nil)
+}
+
+// ReverseIterator returns an iterator over the [key, value] pairs of the trie in reverse order
+// from the start key (included), or from the last key if start is nil.
+func (t *Trie) ReverseIterator(start []byte) *Iterator {
+	var hexStart []byte
+	if start != nil {
+		hexStart = encoding.ToHex(start)
+	}
+
+	it := t.newIterator(hexStart, nil, nil)
+	it.reverse = true
+
+	return it
+}
+
+// Scan returns an iterator over the [key, value] pairs of the trie whose key starts with the prefix.
+// Only the sub-trie matching the prefix is visited.
+func (t *Trie) Scan(prefix []byte) *Iterator {
+	hexPrefix := encoding.ToHex(prefix)
+	hexPrefix = hexPrefix[:len(hexPrefix)-1] // The keys continue after the prefix.
+
+	return t.newIterator(hexPrefix, nil, hexPrefix)
+}
+
+// Range returns an iterator over the [key, value] pairs of the trie
+// from start (included) to end (excluded), or to the last key if end is nil.
+func (t *Trie) Range(start, end []byte) *Iterator {
+	var hexEnd []byte
+	if end != nil {
+		hexEnd = encoding.ToHex(end)
+		hexEnd = hexEnd[:len(hexEnd)-1] // All the keys under the end key come after it.
+	}
+
+	return t.newIterator(encoding.ToHex(start), hexEnd, nil)
+}
+
+func (t *Trie) newIterator(start, end, prefix []byte) *Iterator {
+	it := &Iterator{trie: t, start: start, end: end, prefix: prefix}
+	if t.root != nil {
+		it.stack = append(it.stack, &iteratorFrame{node: t.root})
+	}
+
+	return it
+}
+
+// Next moves the iterator to the next [key, value] pair.
+// It returns false when the iteration is over or an error occurred.
+func (it *Iterator) Next() bool {
+	for it.err == nil && len(it.stack) > 0 {
+		top := it.stack[len(it.stack)-1]
+
+		switch current := top.node.(type) {
+		case node.Leaf:
+			it.stack = it.stack[:len(it.stack)-1]
+			it.key, it.value = encoding.FromHex(top.path), current
+
+			return true
+
+		case *node.Extension:
+			it.stack = it.stack[:len(it.stack)-1]
+			it.push(current.Next, append(top.path[:len(top.path):len(top.path)], current.Key...))
+
+		case *node.Branch:
+			if top.index == len(branchOrder) {
+				it.stack = it.stack[:len(it.stack)-1]
+				continue
+			}
+
+			i := branchOrder[top.index]
+			if it.reverse {
+				i = reverseBranchOrder[top.index]
+			}
+
+			top.index += 1
+			it.push(current.Children[i], append(top.path[:len(top.path):len(top.path)], i))
+
+		case node.Hashed:
+			if top.node, it.err = it.trie.loadHashed(top.path, current); it.err != nil {
+				top.node = current
+			}
+
+		default:
+			it.err = fmt.Errorf("%w: %T unknown", ErrNodeType, current)
+		}
+	}
+
+	it.key, it.value = nil, nil
+
+	return false
+}
+
+// Key returns the key of the current pair. It must not be modified.
+func (it *Iterator) Key() []byte { return it.key }
+
+// Value returns the value of the current pair. It must not be modified.
+func (it *Iterator) Value() []byte { return it.value }
+
+// Err returns the error which stopped the iteration, if any.
+func (it *Iterator) Err() error { return it.err }
+
+// push adds a node to visit, unless all the keys under its path are out of the bounds.
+func (it *Iterator) push(n node.Node, path []byte) {
+	switch {
+	case n == nil:
+		return
+
+	case it.reverse:
+		if it.start != nil && encoding.CompareHex(path, it.start[:min(len(path), len(it.start))]) > 0 {
+			return // All the keys under the path come after the start.
+		}
+
+	case encoding.CompareHex(path, it.start[:min(len(path), len(it.start))]) < 0:
+		return // All the keys under the path come before the start.
+
+	case len(it.prefix) > 0 && encoding.CommonPrefixLen(path, it.prefix) < min(len(path), len(it.prefix)):
+		if encoding.CompareHex(path, it.prefix) > 0 {
+			it.stack = it.stack[:0] // All the remaining keys come after the prefix.
+		}
+
+		return
+
+	case it.end != nil:
+		if afterEnd := encoding.CompareHex(path, it.end[:min(len(path), len(it.end))]); afterEnd > 0 ||
+			(afterEnd == 0 && len(path) >= len(it.end)) {
+			it.stack = it.stack[:0] // All the remaining keys come at or after the end.
+			return
+		}
+	}
+
+	it.stack = append(it.stack, &iteratorFrame{node: n, path: path})
+}
@@ -0,0 +1,322 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestTrieIterator(t *testing.T) {
+	starts := [][]byte{nil, []byte("d"), []byte("do"), []byte("dog"), []byte("dogs"), []byte("horse"), []byte("z")}
+
+	for _, commit := range []bool{false, true} {
+		for _, start := range starts {
+			t.Run(fmt.Sprintf("Iterator[start=%s]%s", start, suffix(t, commit)), func(t *testing.T) {
+				t.Parallel()
+
+				mpt, cleanup := trieSetup(t, commit)
+				defer cleanup()
+
+				var expected []pair
+				for _, node := range sortedNodes() {
+					if bytes.Compare(node.key, start) >= 0 {
+						expected = append(expected, node)
+					}
+				}
+
+				assertIterator(t, mpt.(*Trie).Iterator(start), expected)
+			})
+		}
+	}
+
+	t.Run("Iterator[random]", func(t *testing.T) {
+		t.Parallel()
+
+		mpt, _, pairs := randomTrieFixture(t, 500)
+		r := rand.New(rand.NewSource(1))
+
+		assertIterator(t, mpt.Iterator(nil), pairs)
+
+		for i := 0; i < 20; i++ {
+			start := pairs[r.Intn(len(pairs))].key
+			if i%2 == 1 { // Start from a key which may not be in the trie.
+				start = start[:len(start)/2]
+			}
+
+			var expected []pair
+			for _, p := range pairs {
+				if bytes.Compare(p.key, start) >= 0 {
+					expected = append(expected, p)
+				}
+			}
+
+			assertIterator(t, mpt.Iterator(start), expected)
+		}
+	})
+
+	t.Run("Iterator[empty]", func(t *testing.T) {
+		t.Parallel()
+
+		assertIterator(t, NewEmptyTrie(nil).Iterator(nil), nil)
+	})
+}
+
+func TestTrieReverseIterator(t *testing.T) {
+	starts := [][]byte{nil, []byte("a"), []byte("do"), []byte("doe"), []byte("dog"), []byte("dogs"), []byte("horse"), []byte("z")}
+
+	for _, commit := range []bool{false, true} {
+		for _, start := range starts {
+			t.Run(fmt.Sprintf("ReverseIterator[start=%s]%s", start, suffix(t, commit)), func(t *testing.T) {
+				t.Parallel()
+
+				mpt, cleanup := trieSetup(t, commit)
+				defer cleanup()
+
+				sorted := sortedNodes()
+
+				var expected []pair
+				for i := len(sorted) - 1; i >= 0; i-- {
+					if start == nil || bytes.Compare(sorted[i].key, start) <= 0 {
+						expected = append(expected, sorted[i])
+					}
+				}
+
+				assertIterator(t, mpt.(*Trie).ReverseIterator(start), expected)
+			})
+		}
+	}
+
+	t.Run("ReverseIterator[random]", func(t *testing.T) {
+		t.Parallel()
+
+		mpt, _, pairs := randomTrieFixture(t, 500)
+		r := rand.New(rand.NewSource(5))
+
+		reversed := slices.Clone(pairs)
+		slices.Reverse(reversed)
+
+		assertIterator(t, mpt.ReverseIterator(nil), reversed)
+
+		for i := 0; i < 20; i++ {
+			start := pairs[r.Intn(len(pairs))].key
+			if i%2 == 1 { // Start from a key which may not be in the trie.
+				start = start[:len(start)/2]
+			}
+
+			var expected []pair
+			for _, p := range reversed {
+				if bytes.Compare(p.key, start) <= 0 {
+					expected = append(expected, p)
+				}
+			}
+
+			assertIterator(t, mpt.ReverseIterator(start), expected)
+		}
+	})
+
+	t.Run("ReverseIterator[empty]", func(t *testing.T) {
+		t.Parallel()
+
+		assertIterator(t, NewEmptyTrie(nil).ReverseIterator(nil), nil)
+	})
+}
+
+func TestTrieIteratorMissingNode(t *testing.T) {
+	db, cleanup := storageFixture(t)
+	defer cleanup()
+
+	root, err := trieFixture(t, db).Commit()
+	if err != nil {
+		t.Fatalf("Expected trie to be committed, got err=%s", err)
+	}
+
+	if err = db.Delete(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	it := LoadTrie(db, root).Iterator(nil)
+	if it.Next() {
+		t.Errorf("Expected iteration to stop, got key=%s", it.Key())
+	}
+
+	var missing *MissingNodeError
+	if !errors.As(it.Err(), &missing) {
+		t.Errorf("Expected a missing node error, got err=%s", it.Err())
+	}
+}
+
+func TestTrieScan(t *testing.T) {
+	prefixes := [][]byte{nil, []byte("d"), []byte("do"), []byte("dog"), []byte("doge"), []byte("dogs"), []byte("h"), []byte("z")}
+
+	for _, commit := range []bool{false, true} {
+		for _, prefix := range prefixes {
+			t.Run(fmt.Sprintf("Scan[prefix=%s]%s", prefix, suffix(t, commit)), func(t *testing.T) {
+				t.Parallel()
+
+				mpt, cleanup := trieSetup(t, commit)
+				defer cleanup()
+
+				var expected []pair
+				for _, node := range sortedNodes() {
+					if bytes.HasPrefix(node.key, prefix) {
+						expected = append(expected, node)
+					}
+				}
+
+				assertIterator(t, mpt.(*Trie).Scan(prefix), expected)
+			})
+		}
+	}
+
+	t.Run("Scan[random]", func(t *testing.T) {
+		t.Parallel()
+
+		mpt, _, pairs := randomTrieFixture(t, 500)
+		r := rand.New(rand.NewSource(2))
+
+		for i := 0; i < 20; i++ {
+			key := pairs[r.Intn(len(pairs))].key
+			prefix := key[:r.Intn(len(key)+1)]
+
+			var expected []pair
+			for _, p := range pairs {
+				if bytes.HasPrefix(p.key, prefix) {
+					expected = append(expected, p)
+				}
+			}
+
+			assertIterator(t, mpt.Scan(prefix), expected)
+		}
+	})
+}
+
+func TestTrieScanSubTrie(t *testing.T) {
+	db, cleanup := storageFixture(t)
+	defer cleanup()
+
+	mpt := NewEmptyTrie(db)
+	r := rand.New(rand.NewSource(3))
+
+	var expected []pair
+	for _, prefix := range []byte("ab") {
+		for i := 0; i < 50; i++ {
+			key, val := append([]byte{prefix}, byte(i)), make([]byte, 32)
+			r.Read(val)
+
+			mpt.Put(key, val)
+			if prefix == 'a' {
+				expected = append(expected, pair{key: key, val: val})
+			}
+		}
+	}
+
+	root, err := mpt.Commit()
+	if err != nil {
+		t.Fatalf("Expected trie to be committed, got err=%s", err)
+	}
+
+	// Remove the sub-trie of the keys starting with 'b' (0x62).
+	if err = db.Delete([]byte{6, 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	assertIterator(t, LoadTrie(db, root).Scan([]byte("a")), expected)
+	assertIterator(t, LoadTrie(db, root).Range([]byte("a"), []byte("b")), expected)
+
+	var missing *MissingNodeError
+	if it := LoadTrie(db, root).Scan([]byte("b")); it.Next() || !errors.As(it.Err(), &missing) {
+		t.Errorf("Expected a missing node error, got err=%s", it.Err())
+	}
+}
+
+func TestTrieRange(t *testing.T) {
+	bounds := [][2][]byte{
+		{nil, nil},
+		{nil, []byte("dog")},
+		{[]byte("do"), []byte("doge")},
+		{[]byte("do"), []byte("dogf")},
+		{[]byte("dog"), []byte("horse")},
+		{[]byte("dog"), []byte("dog")},
+		{[]byte("e"), []byte("z")},
+		{[]byte("horse"), nil},
+	}
+
+	for _, commit := range []bool{false, true} {
+		for _, bound := range bounds {
+			start, end := bound[0], bound[1]
+
+			t.Run(fmt.Sprintf("Range[start=%s,end=%s]%s", start, end, suffix(t, commit)), func(t *testing.T) {
+				t.Parallel()
+
+				mpt, cleanup := trieSetup(t, commit)
+				defer cleanup()
+
+				var expected []pair
+				for _, node := range sortedNodes() {
+					if bytes.Compare(node.key, start) >= 0 && (end == nil || bytes.Compare(node.key, end) < 0) {
+						expected = append(expected, node)
+					}
+				}
+
+				assertIterator(t, mpt.(*Trie).Range(start, end), expected)
+			})
+		}
+	}
+
+	t.Run("Range[random]", func(t *testing.T) {
+		t.Parallel()
+
+		mpt, _, pairs := randomTrieFixture(t, 500)
+		r := rand.New(rand.NewSource(4))
+
+		for i := 0; i < 20; i++ {
+			start, end := pairs[r.Intn(len(pairs))].key, pairs[r.Intn(len(pairs))].key
+			if bytes.Compare(start, end) > 0 {
+				start, end = end, start
+			}
+
+			if i%2 == 1 { // Use bounds which may not be in the trie.
+				start, end = start[:len(start)/2], end[:len(end)/2]
+			}
+
+			var expected []pair
+			for _, p := range pairs {
+				if bytes.Compare(p.key, start) >= 0 && bytes.Compare(p.key, end) < 0 {
+					expected = append(expected, p)
+				}
+			}
+
+			assertIterator(t, mpt.Range(start, end), expected)
+		}
+	})
+}
+
+func assertIterator(t *testing.T, it *Iterator, expected []pair) {
+	t.Helper()
+
+	i := 0
+	for ; it.Next(); i++ {
+		if i >= len(expected) {
+			t.Errorf("Unexpected key=%x", it.Key())
+			continue
+		}
+
+		if !bytes.Equal(it.Key(), expected[i].key) || !bytes.Equal(it.Value(), expected[i].val) {
+			t.Errorf("Expected key=%x val=%x at %d, got key=%x val=%x",
+				expected[i].key, expected[i].val, i, it.Key(), it.Value())
+		}
+	}
+
+	if it.Err() != nil {
+		t.Errorf("Expected iteration to succeed, got err=%s", it.Err())
+	}
+
+	if i != len(expected) {
+		t.Errorf("Expected %d pairs, got %d pairs", len(expected), i)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"errors"
+
+	"go.0xjac.com/tfmpt/encoding"
+)
+
+// MultiProof returns the Merkle-proofs of all the keys as a single list of RLP encoded nodes,
+// where the nodes shared between proofs, such as the root, are only included once.
+// Missing keys are proven absent.
+func (t *Trie) MultiProof(keys [][]byte) ([][]byte, error) {
+	var nodes proofSet
+
+	for _, key := range keys {
+		proof, err := t.Proof(key)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes.add(proof)
+	}
+
+	return nodes.nodes, nil
+}
+
+// VerifyMultiProof checks the Merkle-proofs of all the keys against the root hash of a trie.
+// The proof is a list of RLP encoded nodes, such as the one from Trie.MultiProof, in any order.
+// Each node is decoded once and shared between the keys.
+// It returns the values of the keys in order, with a nil value for keys proven absent,
+// or a ProofError if the proof of any key is invalid.
+func VerifyMultiProof(root []byte, keys [][]byte, proof [][]byte) ([][]byte, error) {
+	nodes := newProofNodes(proof)
+	values := make([][]byte, len(keys))
+
+	for i, key := range keys {
+		value, err := nodes.verify(root, encoding.ToHex(key))
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+
+		values[i] = value
+	}
+
+	return values, nil
+}
@@ -0,0 +1,101 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.0xjac.com/tfmpt/crypto"
+)
+
+func TestTrieMultiProof(t *testing.T) {
+	keys := append([][]byte{}, missings...)
+	for _, node := range nodes {
+		keys = append(keys, node.key)
+	}
+
+	for _, commit := range []bool{false, true} {
+		t.Run(fmt.Sprintf("MultiProof%s", suffix(t, commit)), func(t *testing.T) {
+			t.Parallel()
+			ethMPT := ethTrieFixture(t)
+
+			mpt, cleanup := trieSetup(t, commit)
+			defer cleanup()
+
+			proof, err := mpt.(*Trie).MultiProof(keys)
+			if err != nil {
+				t.Fatalf("Expected a multiproof, got err=%s", err)
+			}
+
+			expected := newMockEthProofDB(len(proof))
+			for _, key := range keys {
+				if err = ethMPT.Prove(key, expected); err != nil {
+					t.Fatalf("Expected a proof for key=%s, got err=%s", key, err)
+				}
+			}
+
+			if len(proof) != len(expected) {
+				t.Errorf("Expected %d unique nodes, got %d nodes", len(expected), len(proof))
+			}
+
+			for _, part := range proof {
+				if rlpEnc, ok := expected[string(crypto.Keccak256(part))]; !ok || !bytes.Equal(part, rlpEnc) {
+					t.Errorf("Bad proof part=%x", part)
+				}
+			}
+
+			values, err := VerifyMultiProof(mpt.Hash(), keys, proof)
+			if err != nil {
+				t.Fatalf("Expected multiproof to be valid, got err=%s", err)
+			}
+
+			for i, key := range missings {
+				if values[i] != nil {
+					t.Errorf("Expected key=%s to be missing, got val=%s", key, values[i])
+				}
+			}
+
+			for i, node := range nodes {
+				if val := values[len(missings)+i]; !bytes.Equal(val, node.val) {
+					t.Errorf("Expected key=%s to be %s, got val=%s", node.key, node.val, val)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyMultiProofInvalid(t *testing.T) {
+	mpt, root, pairs := randomTrieFixture(t, 500)
+
+	keys := make([][]byte, 0, len(pairs))
+	for _, p := range pairs {
+		keys = append(keys, p.key)
+	}
+
+	proof, err := mpt.MultiProof(keys)
+	if err != nil {
+		t.Fatalf("Expected a multiproof, got err=%s", err)
+	}
+
+	values, err := VerifyMultiProof(root, keys, proof)
+	if err != nil {
+		t.Fatalf("Expected multiproof to be valid, got err=%s", err)
+	}
+
+	for i, p := range pairs {
+		if !bytes.Equal(values[i], p.val) {
+			t.Errorf("Expected key=%x to be %x, got val=%x", p.key, p.val, values[i])
+		}
+	}
+
+	for i := range proof {
+		truncated := append(append([][]byte{}, proof[:i]...), proof[i+1:]...)
+
+		if _, err = VerifyMultiProof(root, keys, truncated); !errors.Is(err, ErrInvalidProof) {
+			t.Errorf("Expected multiproof without node=%x to be invalid, got err=%s", proof[i], err)
+		}
+	}
+}
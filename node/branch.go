@@ -28,24 +28,27 @@ func (b *Branch) Hash() Node {
 		return b.Cache
 	}
 
-	hashed := b.Copy()
+	hash := hashNode(b.collapse())
+	if cache, ok := hash.(Hashed); ok { // Embedded nodes are not cached, so they are never written to.
+		b.Cache = cache
+	}
+
+	return hash
+}
+
+// collapse returns a copy of the branch where every child is replaced by its hash.
+func (b *Branch) collapse() *Branch {
+	collapsed := b.Copy()
 
 	for i := 0; i < BranchChildren; i++ {
 		if child := b.Children[i]; child != nil {
-			hashed.Children[i] = child.Hash()
+			collapsed.Children[i] = child.Hash()
 		} else {
-			hashed.Children[i] = nil
+			collapsed.Children[i] = nil
 		}
 	}
 
-	hash := hashNode(hashed)
-	if cache, ok := hash.(Hashed); ok {
-		b.Cache = cache
-	} else {
-		b.Cache = nil
-	}
-
-	return hash
+	return collapsed
 }
 
 func (b *Branch) EncodeRLP(w io.Writer) error {
@@ -13,3 +13,11 @@ func ExampleDecode() {
 	// Output:
 	// [06 05 07 09 10, val]
 }
+
+func ExampleEncode() {
+	ext := node.NewExtension([]byte{0x06, 0x0b, 0x06, 0x05, 0x07, 0x09, 0x10}, node.Leaf("val"), nil)
+	enc, _ := node.Encode(ext)
+	fmt.Printf("% x\n", enc)
+	// Output:
+	// c9 84 20 6b 65 79 83 76 61 6c
+}
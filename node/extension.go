@@ -26,23 +26,26 @@ func (e *Extension) Hash() Node {
 		return e.Cache
 	}
 
-	hashed := e.Copy()
+	hash := hashNode(e.collapse())
+	if cache, ok := hash.(Hashed); ok { // Embedded nodes are not cached, so they are never written to.
+		e.Cache = cache
+	}
+
+	return hash
+}
+
+// collapse returns a copy of the extension with a compact key and the next node replaced by its hash.
+func (e *Extension) collapse() *Extension {
+	collapsed := e.Copy()
 
-	hashed.Key = encoding.Compact(e.Key)
+	collapsed.Key = encoding.Compact(e.Key)
 
 	switch e.Next.(type) {
 	case *Branch, *Extension:
-		hashed.Next = e.Next.Hash()
+		collapsed.Next = e.Next.Hash()
 	}
 
-	hash := hashNode(hashed)
-	if cache, ok := hash.(Hashed); ok {
-		e.Cache = cache
-	} else {
-		e.Cache = nil
-	}
-
-	return hash
+	return collapsed
 }
 
 func (e *Extension) EncodeRLP(w io.Writer) error {
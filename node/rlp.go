@@ -24,6 +24,19 @@ func hashNode(n Node) Node {
 	return Hashed(crypto.Keccak256(rlpEnc))
 }
 
+// Encode returns the RLP encoding of a node as it is hashed and stored,
+// that is with its children replaced by their hash and its key compacted.
+func Encode(n Node) ([]byte, error) {
+	switch current := n.(type) {
+	case *Branch:
+		return rlp.EncodeToBytes(current.collapse())
+	case *Extension:
+		return rlp.EncodeToBytes(current.collapse())
+	default:
+		return rlp.EncodeToBytes(current)
+	}
+}
+
 func Decode(raw []byte, hashed Hashed) (Node, error) {
 	items, _, err := rlp.SplitList(raw)
 	if err != nil {
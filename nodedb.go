@@ -0,0 +1,187 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"go.0xjac.com/tfmpt/node"
+	"go.0xjac.com/tfmpt/store"
+)
+
+var (
+	ErrNotReferenced = errors.New("node is not referenced")
+
+	// refPrefix prefixes the keys of the reference counts of the nodes in the store.
+	refPrefix = []byte("tfmpt-refs-")
+)
+
+// NodeDB counts the references to the nodes of the tries committed to a store with the HashScheme,
+// so that the nodes which are no longer referenced by any root can be deleted.
+//
+// The count of a node is the number of nodes and roots referencing it. When a root is referenced,
+// its nodes which were not referenced yet reference their children. When a root is dereferenced,
+// its nodes which are no longer referenced are deleted and dereference their children.
+// Every root committed must be referenced for its nodes to be deleted once dereferenced.
+type NodeDB struct {
+	trie *Trie // trie holds the options of the tries in the store and loads their nodes.
+}
+
+// NewNodeDB returns the node database of the tries in the store with the given options,
+// which must be the HashScheme and the same owner as the tries.
+func NewNodeDB(db store.DB, opts ...Option) *NodeDB {
+	return &NodeDB{trie: NewEmptyTrie(db, opts...)}
+}
+
+// Reference adds a reference to the root, and to all its nodes not referenced yet.
+func (ndb *NodeDB) Reference(root []byte) error {
+	return ndb.update(root, (*refUpdate).reference)
+}
+
+// Dereference removes a reference to the root, and deletes all its nodes which are no longer referenced.
+func (ndb *NodeDB) Dereference(root []byte) error {
+	return ndb.update(root, (*refUpdate).dereference)
+}
+
+// References returns the number of references to the node with the given hash.
+func (ndb *NodeDB) References(hashed []byte) (uint64, error) {
+	if ndb.trie.db == nil {
+		return 0, ErrNoDB
+	}
+
+	return (&refUpdate{ndb: ndb}).count(hashed)
+}
+
+func (ndb *NodeDB) update(root []byte, op func(*refUpdate, []byte, node.Hashed) error) error {
+	switch {
+	case ndb.trie.readOnly:
+		return ErrReadOnly
+	case ndb.trie.db == nil:
+		return ErrNoDB
+	case ndb.trie.scheme != HashScheme:
+		return fmt.Errorf("%w: reference counting requires the %s scheme", ErrScheme, HashScheme)
+	case bytes.Equal(root, emptyRoot):
+		return nil
+	}
+
+	update := &refUpdate{ndb: ndb, counts: make(map[string]uint64)}
+	if err := op(update, nil, root); err != nil {
+		return err
+	}
+
+	for hashed, count := range update.counts {
+		write := storeWrite{key: ndb.refKey([]byte(hashed))}
+		if count > 0 {
+			write.value = binary.BigEndian.AppendUint64(nil, count)
+		}
+
+		update.writes = append(update.writes, write)
+	}
+
+	return update.writes.apply(ndb.trie.db)
+}
+
+// refKey returns the key in the store of the reference count of the node with the given hash.
+func (ndb *NodeDB) refKey(hashed []byte) []byte {
+	key := make([]byte, 0, len(refPrefix)+len(ndb.trie.owner)+len(hashed))
+	return append(append(append(key, refPrefix...), ndb.trie.owner...), hashed...)
+}
+
+// refUpdate holds the reference counts changed by a (de)reference and the nodes to delete,
+// until they are written to the store in a single batch.
+type refUpdate struct {
+	ndb    *NodeDB
+	counts map[string]uint64
+	writes storeWrites
+}
+
+func (u *refUpdate) count(hashed []byte) (uint64, error) {
+	if count, ok := u.counts[string(hashed)]; ok {
+		return count, nil
+	}
+
+	raw, err := u.ndb.trie.db.Get(u.ndb.refKey(hashed))
+	switch {
+	case errors.Is(err, store.ErrNotFound) || (err == nil && raw == nil):
+		return 0, nil
+	case err != nil:
+		return 0, err
+	case len(raw) != 8:
+		return 0, fmt.Errorf("bad reference count for node %x: %x", hashed, raw)
+	}
+
+	return binary.BigEndian.Uint64(raw), nil
+}
+
+func (u *refUpdate) reference(path []byte, hashed node.Hashed) error {
+	count, err := u.count(hashed)
+	if err != nil {
+		return err
+	}
+
+	u.counts[string(hashed)] = count + 1
+	if count > 0 {
+		return nil // The children are already referenced by the node.
+	}
+
+	n, err := u.ndb.trie.loadHashed(path, hashed)
+	if err != nil {
+		return err
+	}
+
+	return hashedChildren(n, path, u.reference)
+}
+
+func (u *refUpdate) dereference(path []byte, hashed node.Hashed) error {
+	count, err := u.count(hashed)
+	if err != nil {
+		return err
+	} else if count == 0 {
+		return fmt.Errorf("%w: %x at path %x", ErrNotReferenced, hashed, path)
+	}
+
+	u.counts[string(hashed)] = count - 1
+	if count > 1 {
+		return nil // The node is still referenced.
+	}
+
+	n, err := u.ndb.trie.loadHashed(path, hashed)
+	if err != nil {
+		return err
+	}
+
+	u.writes = append(u.writes, storeWrite{key: u.ndb.trie.nodeKey(path, hashed)})
+
+	return hashedChildren(n, path, u.dereference)
+}
+
+// hashedChildren calls fn with each hashed child of the node, including the ones of its embedded children.
+func hashedChildren(n node.Node, path []byte, fn func([]byte, node.Hashed) error) error {
+	child := func(path []byte, n node.Node) error {
+		switch current := n.(type) {
+		case node.Hashed:
+			return fn(path, current)
+		case *node.Branch, *node.Extension:
+			return hashedChildren(current, path, fn)
+		default:
+			return nil
+		}
+	}
+
+	switch current := n.(type) {
+	case *node.Branch:
+		for i := 0; i < node.BranchChildren; i++ {
+			if err := child(append(path[:len(path):len(path)], byte(i)), current.Children[i]); err != nil {
+				return err
+			}
+		}
+
+	case *node.Extension:
+		return child(append(path[:len(path):len(path)], current.Key...), current.Next)
+	}
+
+	return nil
+}
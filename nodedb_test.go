@@ -0,0 +1,87 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"errors"
+	"testing"
+
+	"go.0xjac.com/tfmpt/store"
+)
+
+func TestNodeDB(t *testing.T) {
+	db := store.NewMemoryDB()
+	ndb := NewNodeDB(db, WithScheme(HashScheme))
+
+	_, _, pairs := randomTrieFixture(t, 300)
+	mpt := NewEmptyTrie(db, WithScheme(HashScheme))
+
+	// Commit and reference 3 versions, each updating a third of the keys.
+	var roots [][]byte
+	for v := 0; v < 3; v++ {
+		for i, p := range pairs {
+			if v == 0 || i%3 == v {
+				mpt.Put(p.key, append([]byte{byte(v)}, p.val...))
+			}
+		}
+
+		root, err := mpt.Commit()
+		if err != nil {
+			t.Fatalf("Expected version=%d to be committed, got err=%s", v, err)
+		}
+
+		if err = ndb.Reference(root); err != nil {
+			t.Fatalf("Expected version=%d to be referenced, got err=%s", v, err)
+		}
+
+		roots = append(roots, root)
+	}
+
+	// Once the oldest versions are dereferenced, their nodes are deleted but the latest one is intact.
+	for v, root := range roots[:2] {
+		size := db.Len()
+		if err := ndb.Dereference(root); err != nil {
+			t.Fatalf("Expected version=%d to be dereferenced, got err=%s", v, err)
+		}
+
+		if db.Len() >= size {
+			t.Errorf("Expected nodes of version=%d to be deleted, got %d keys from %d keys", v, db.Len(), size)
+		}
+
+		var missing *MissingNodeError
+		if _, err := LoadTrie(db, root, WithScheme(HashScheme)).Get(pairs[0].key); !errors.As(err, &missing) {
+			t.Errorf("Expected root of version=%d to be deleted, got err=%s", v, err)
+		}
+	}
+
+	latest := LoadTrie(db, roots[2], WithScheme(HashScheme))
+	for i, p := range pairs {
+		val, err := latest.Get(p.key)
+		assertPresent(t, p.key, val, append([]byte{[]byte{0, 1, 2}[i%3]}, p.val...), err)
+	}
+
+	if refs, err := ndb.References(roots[2]); err != nil || refs != 1 {
+		t.Errorf("Expected 1 reference to the latest root, got refs=%d err=%s", refs, err)
+	}
+
+	// Once the latest version is dereferenced, nothing is left in the store.
+	if err := ndb.Dereference(roots[2]); err != nil {
+		t.Fatalf("Expected latest version to be dereferenced, got err=%s", err)
+	}
+
+	if db.Len() != 0 {
+		t.Errorf("Expected an empty store, got %d keys", db.Len())
+	}
+
+	if err := ndb.Dereference(roots[2]); !errors.Is(err, ErrNotReferenced) {
+		t.Errorf("Expected dereference to fail with err=%s, got err=%s", ErrNotReferenced, err)
+	}
+}
+
+func TestNodeDBScheme(t *testing.T) {
+	db, root, _ := committedTrieFixture(t, 10)
+
+	if err := NewNodeDB(db).Reference(root); !errors.Is(err, ErrScheme) {
+		t.Errorf("Expected reference to fail with err=%s, got err=%s", ErrScheme, err)
+	}
+}
@@ -0,0 +1,205 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"fmt"
+
+	"go.0xjac.com/tfmpt/crypto"
+	"go.0xjac.com/tfmpt/node"
+)
+
+// NodeKind is the kind of node a NodeIterator is at.
+type NodeKind int
+
+const (
+	NodeUnknown   NodeKind = iota
+	NodeBranch             // NodeBranch is a *node.Branch.
+	NodeExtension          // NodeExtension is a *node.Extension, including leaves whose key ends with the terminator.
+	NodeValue              // NodeValue is a node.Leaf, the value of a key.
+	NodeHashed             // NodeHashed is a node.Hashed, not yet loaded from the store.
+)
+
+func (k NodeKind) String() string {
+	switch k {
+	case NodeBranch:
+		return "branch"
+	case NodeExtension:
+		return "extension"
+	case NodeValue:
+		return "value"
+	case NodeHashed:
+		return "hashed"
+	default:
+		return "unknown"
+	}
+}
+
+// NodeIterator walks the nodes of a trie in pre-order, following the lexicographic order of the keys.
+// A hashed node is visited before the node it resolves to, which is loaded from the store at the same path
+// when the iterator descends into it. The trie must not be modified while it is iterated over.
+type NodeIterator struct {
+	trie    *Trie
+	stack   []*iteratorFrame
+	started bool
+	err     error
+}
+
+// NodeIterator returns an iterator over the nodes of the trie, starting at the root.
+func (t *Trie) NodeIterator() *NodeIterator {
+	return &NodeIterator{trie: t}
+}
+
+// Next moves the iterator to the next node. If descend is false, the children of the current node are skipped.
+// It returns false when the iteration is over or an error occurred.
+func (it *NodeIterator) Next(descend bool) bool {
+	if it.err != nil {
+		return false
+	}
+
+	if !it.started {
+		it.started = true
+		if it.trie.root == nil {
+			return false
+		}
+
+		it.stack = append(it.stack, &iteratorFrame{node: it.trie.root})
+
+		return true
+	}
+
+	if !descend && len(it.stack) > 0 {
+		it.stack = it.stack[:len(it.stack)-1]
+	}
+
+	for len(it.stack) > 0 {
+		top := it.stack[len(it.stack)-1]
+
+		child, path, err := it.nextChild(top)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		if child != nil {
+			it.stack = append(it.stack, &iteratorFrame{node: child, path: path})
+			return true
+		}
+
+		it.stack = it.stack[:len(it.stack)-1]
+	}
+
+	return false
+}
+
+// nextChild returns the next child of a node to visit, with its path, or nil if all its children were visited.
+func (it *NodeIterator) nextChild(frame *iteratorFrame) (node.Node, []byte, error) {
+	switch current := frame.node.(type) {
+	case *node.Branch:
+		for frame.index < len(branchOrder) {
+			i := branchOrder[frame.index]
+			frame.index += 1
+
+			if child := current.Children[i]; child != nil {
+				return child, append(frame.path[:len(frame.path):len(frame.path)], i), nil
+			}
+		}
+
+	case *node.Extension:
+		if frame.index == 0 {
+			frame.index += 1
+			return current.Next, append(frame.path[:len(frame.path):len(frame.path)], current.Key...), nil
+		}
+
+	case node.Hashed:
+		if frame.index == 0 {
+			frame.index += 1
+
+			resolved, err := it.trie.loadHashed(frame.path, current)
+
+			return resolved, frame.path, err
+		}
+
+	case node.Leaf:
+
+	default:
+		return nil, nil, fmt.Errorf("%w: %T unknown", ErrNodeType, current)
+	}
+
+	return nil, nil, nil
+}
+
+// Path returns the hex-encoded path of the current node from the root.
+// The path of a value ends with the terminator. It must not be modified.
+func (it *NodeIterator) Path() []byte {
+	if len(it.stack) == 0 {
+		return nil
+	}
+
+	return it.stack[len(it.stack)-1].path
+}
+
+// Node returns the current node. It must not be modified.
+func (it *NodeIterator) Node() node.Node {
+	if len(it.stack) == 0 {
+		return nil
+	}
+
+	return it.stack[len(it.stack)-1].node
+}
+
+// Kind returns the kind of the current node.
+func (it *NodeIterator) Kind() NodeKind {
+	switch it.Node().(type) {
+	case *node.Branch:
+		return NodeBranch
+	case *node.Extension:
+		return NodeExtension
+	case node.Leaf:
+		return NodeValue
+	case node.Hashed:
+		return NodeHashed
+	default:
+		return NodeUnknown
+	}
+}
+
+// Hash returns the hash of the current node, or nil if the node is embedded in its parent.
+// The root node always has a hash.
+func (it *NodeIterator) Hash() node.Hashed {
+	switch current := it.Node().(type) {
+	case nil, node.Leaf:
+		return nil
+
+	case node.Hashed:
+		return current
+
+	default:
+		if hashed, ok := current.Hash().(node.Hashed); ok {
+			return hashed
+		}
+
+		if len(it.Path()) > 0 {
+			return nil
+		}
+
+		rlpEnc, err := node.Encode(current)
+		if err != nil {
+			return nil
+		}
+
+		return crypto.Keccak256(rlpEnc)
+	}
+}
+
+// RLP returns the RLP encoding of the current node as it is hashed and stored.
+func (it *NodeIterator) RLP() ([]byte, error) {
+	if it.Node() == nil {
+		return nil, nil
+	}
+
+	return node.Encode(it.Node())
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *NodeIterator) Err() error { return it.err }
@@ -0,0 +1,202 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/trie"
+
+	"go.0xjac.com/tfmpt/crypto"
+	"go.0xjac.com/tfmpt/encoding"
+	"go.0xjac.com/tfmpt/node"
+)
+
+func TestTrieNodeIterator(t *testing.T) {
+	for _, commit := range []bool{false, true} {
+		t.Run(fmt.Sprintf("NodeIterator%s", suffix(t, commit)), func(t *testing.T) {
+			t.Parallel()
+
+			mpt, cleanup := trieSetup(t, commit)
+			defer cleanup()
+
+			assertNodeIterator(t, mpt.(*Trie), ethTrieFixture(t))
+		})
+	}
+
+	t.Run("NodeIterator[random]", func(t *testing.T) {
+		t.Parallel()
+
+		db, cleanup := storageFixture(t)
+		defer cleanup()
+
+		_, _, pairs := randomTrieFixture(t, 500)
+
+		mpt, ethMPT := NewEmptyTrie(db), trie.NewEmpty(nil)
+		for _, p := range pairs {
+			mpt.Put(p.key, p.val)
+			ethMPT.MustUpdate(p.key, p.val)
+		}
+
+		root, err := mpt.Commit()
+		if err != nil {
+			t.Fatalf("Expected trie to be committed, got err=%s", err)
+		}
+
+		assertNodeIterator(t, LoadTrie(db, root), ethMPT)
+	})
+
+	t.Run("NodeIterator[empty]", func(t *testing.T) {
+		t.Parallel()
+
+		if it := NewEmptyTrie(nil).NodeIterator(); it.Next(true) {
+			t.Errorf("Expected no node, got kind=%s", it.Kind())
+		}
+	})
+}
+
+func TestTrieNodeIteratorSkip(t *testing.T) {
+	mpt, _, pairs := randomTrieFixture(t, 500)
+
+	it := mpt.NodeIterator()
+	if !it.Next(true) || it.Kind() != NodeBranch {
+		t.Fatalf("Expected the root branch, got kind=%s", it.Kind())
+	}
+
+	// Visit the children of the root without descending into them.
+	var paths [][]byte
+	for it.Next(len(paths) == 0) {
+		paths = append(paths, it.Path())
+
+		if len(it.Path()) != 1 {
+			t.Errorf("Expected a child of the root, got path=%x", it.Path())
+		}
+	}
+
+	if it.Err() != nil {
+		t.Errorf("Expected iteration to succeed, got err=%s", it.Err())
+	}
+
+	var expected [][]byte
+	for _, p := range pairs {
+		nibble := encoding.ToHex(p.key)[:1]
+		if len(expected) == 0 || !bytes.Equal(expected[len(expected)-1], nibble) {
+			expected = append(expected, nibble)
+		}
+	}
+
+	if len(paths) != len(expected) {
+		t.Fatalf("Expected %d children, got %d children", len(expected), len(paths))
+	}
+
+	for i := range paths {
+		if !bytes.Equal(paths[i], expected[i]) {
+			t.Errorf("Expected child path=%x, got path=%x", expected[i], paths[i])
+		}
+	}
+}
+
+func TestTrieNodeIteratorMissingNode(t *testing.T) {
+	db, cleanup := storageFixture(t)
+	defer cleanup()
+
+	root, err := trieFixture(t, db).Commit()
+	if err != nil {
+		t.Fatalf("Expected trie to be committed, got err=%s", err)
+	}
+
+	if err = db.Delete(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	it := LoadTrie(db, root).NodeIterator()
+	if !it.Next(true) || it.Kind() != NodeHashed || !bytes.Equal(it.Hash(), root) {
+		t.Fatalf("Expected the hashed root, got kind=%s hash=%x", it.Kind(), it.Hash())
+	}
+
+	if !it.Next(false) && it.Err() != nil {
+		t.Errorf("Expected skipping the root to succeed, got err=%s", it.Err())
+	}
+
+	it = LoadTrie(db, root).NodeIterator()
+	it.Next(true)
+
+	var missing *MissingNodeError
+	if it.Next(true) || !errors.As(it.Err(), &missing) {
+		t.Errorf("Expected a missing node error, got err=%s", it.Err())
+	}
+}
+
+// assertNodeIterator checks the nodes with a hash and the values walked by the node iterator
+// match the ones walked by the official go-ethereum implementation.
+func assertNodeIterator(t *testing.T, mpt *Trie, ethMPT *trie.Trie) {
+	t.Helper()
+
+	expectedHashes, expectedValues := make(map[string]common.Hash), make(map[string][]byte)
+
+	ethIt, err := ethMPT.NodeIterator(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for ethIt.Next(true) {
+		if ethIt.Leaf() {
+			expectedValues[string(ethIt.Path())] = ethIt.LeafBlob()
+		} else if ethIt.Hash() != (common.Hash{}) {
+			expectedHashes[string(ethIt.Path())] = ethIt.Hash()
+		}
+	}
+
+	hashes, values := make(map[string]common.Hash), make(map[string][]byte)
+
+	it := mpt.NodeIterator()
+	for it.Next(true) {
+		switch kind, hash := it.Kind(), it.Hash(); {
+		case kind == NodeValue:
+			values[string(it.Path())] = it.Node().(node.Leaf)
+
+		case kind == NodeHashed:
+			continue // The resolved node at the same path is checked next.
+
+		case hash != nil:
+			rlpEnc, err := it.RLP()
+			if err != nil {
+				t.Fatalf("Expected node at path=%x to be encoded, got err=%s", it.Path(), err)
+			}
+
+			if !bytes.Equal(crypto.Keccak256(rlpEnc), hash) {
+				t.Errorf("Expected node at path=%x to match its hash=%x", it.Path(), hash)
+			}
+
+			hashes[string(it.Path())] = common.BytesToHash(hash)
+		}
+	}
+
+	if it.Err() != nil {
+		t.Fatalf("Expected iteration to succeed, got err=%s", it.Err())
+	}
+
+	if len(hashes) != len(expectedHashes) {
+		t.Errorf("Expected %d hashed nodes, got %d hashed nodes", len(expectedHashes), len(hashes))
+	}
+
+	for path, expected := range expectedHashes {
+		if hashes[path] != expected {
+			t.Errorf("Expected hash=%s at path=%x, got hash=%s", expected, path, hashes[path])
+		}
+	}
+
+	if len(values) != len(expectedValues) {
+		t.Errorf("Expected %d values, got %d values", len(expectedValues), len(values))
+	}
+
+	for path, expected := range expectedValues {
+		if !bytes.Equal(values[path], expected) {
+			t.Errorf("Expected val=%x at path=%x, got val=%x", expected, path, values[path])
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"github.com/ethereum/go-ethereum/common/lru"
+
+	"go.0xjac.com/tfmpt/node"
+)
+
+// Scheme is the layout of the nodes of a trie in the store.
+type Scheme int
+
+const (
+	// PathScheme stores each node under its hex-encoded path from the root.
+	// Only the latest version of the trie is kept, committing overwrites the nodes of older roots.
+	PathScheme Scheme = iota
+
+	// HashScheme stores each node under its Keccak256 hash.
+	// Nodes are never overwritten nor deleted, so every committed root stays readable.
+	HashScheme
+)
+
+func (s Scheme) String() string {
+	switch s {
+	case PathScheme:
+		return "path"
+	case HashScheme:
+		return "hash"
+	default:
+		return "unknown"
+	}
+}
+
+// Option configures a trie when it is created or loaded.
+type Option func(*Trie)
+
+// WithScheme sets the layout of the nodes in the store. The default is PathScheme.
+// A trie must be loaded with the scheme it was committed with.
+func WithScheme(scheme Scheme) Option {
+	return func(t *Trie) { t.scheme = scheme }
+}
+
+// WithOwner prefixes all the keys of the trie in the store with the owner,
+// such as the hash of an account for its storage trie, so that multiple tries can share a store.
+// All the owners in a store must have the same length for their keys not to collide.
+func WithOwner(owner []byte) Option {
+	return func(t *Trie) { t.owner = append([]byte(nil), owner...) }
+}
+
+// WithHistory keeps the reverse diffs of the last versions committed, so that the trie can be rolled back
+// to any of their roots. It requires the PathScheme.
+func WithHistory(versions uint64) Option {
+	return func(t *Trie) { t.history = versions }
+}
+
+// WithCache keeps up to size decoded nodes in memory, so that the nodes most used are not
+// loaded from the store and decoded again. The cache is disabled if size is 0.
+func WithCache(size int) Option {
+	return func(t *Trie) {
+		t.cache = nil
+		if size > 0 {
+			t.cache = lru.NewCache[string, node.Node](size)
+		}
+	}
+}
+
+// WithReadOnly rejects any change to the trie and to the store with ErrReadOnly.
+// A read-only trie is safe for concurrent use.
+func WithReadOnly() Option {
+	return func(t *Trie) { t.readOnly = true }
+}
+
+// WithHashCheck checks that every node loaded from the store matches the hash referencing it,
+// and fails with a CorruptNodeError otherwise, at the cost of hashing the node.
+func WithHashCheck() Option {
+	return func(t *Trie) { t.hashCheck = true }
+}
+
+// nodeKey returns the key in the store of the node at the path with the given hash.
+func (t *Trie) nodeKey(path []byte, hashed node.Hashed) []byte {
+	key := path
+	if t.scheme == HashScheme {
+		key = hashed
+	}
+
+	if len(t.owner) == 0 {
+		return key
+	}
+
+	return append(t.owner[:len(t.owner):len(t.owner)], key...)
+}
@@ -0,0 +1,179 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"go.0xjac.com/tfmpt/crypto"
+	"go.0xjac.com/tfmpt/encoding"
+	"go.0xjac.com/tfmpt/node"
+)
+
+var (
+	ErrKeyExists        = errors.New("key exists")
+	ErrInvalidProof     = errors.New("invalid proof")
+	ErrProofMissingNode = errors.New("node missing from proof")
+	ErrProofDecode      = errors.New("node decode error")
+)
+
+// ProofError is returned when a proof is invalid. It matches ErrInvalidProof with errors.Is.
+type ProofError struct {
+	Path []byte      // Path is the hex-encoded path of the invalid node from the root.
+	Hash node.Hashed // Hash is the reference to the invalid node in its parent.
+	Err  error       // Err is the reason why the proof is invalid.
+}
+
+func (e *ProofError) Error() string {
+	return fmt.Sprintf("%v: %v for node %064x at path [% x]", ErrInvalidProof, e.Err, []byte(e.Hash), e.Path)
+}
+
+func (e *ProofError) Is(target error) bool { return target == ErrInvalidProof }
+
+func (e *ProofError) Unwrap() error { return e.Err }
+
+// VerifyProof checks the Merkle-proof of a key against the root hash of a trie.
+// The proof is a list of RLP encoded nodes, such as the ones from Trie.Proof, in any order.
+// It returns the value of the key if the proof is valid, ErrNotFound if the proof shows
+// the key is not in the trie, and a ProofError otherwise.
+func VerifyProof(root, key []byte, proof [][]byte) ([]byte, error) {
+	return newProofNodes(proof).verify(root, encoding.ToHex(key))
+}
+
+// VerifyAbsence checks the Merkle-proof that a key is not in the trie with the given root hash.
+// The proof is a list of RLP encoded nodes, such as the ones from Trie.Proof for a missing key.
+// It returns nil if the proof shows the key is absent, ErrKeyExists if the proof shows the key
+// is in the trie, and a ProofError otherwise.
+func VerifyAbsence(root, key []byte, proof [][]byte) error {
+	_, err := VerifyProof(root, key, proof)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return nil
+	case err != nil:
+		return err
+	default:
+		return ErrKeyExists
+	}
+}
+
+// proofSet collects the RLP encoded nodes of several proofs, without duplicates.
+type proofSet struct {
+	seen  map[string]struct{}
+	nodes [][]byte
+}
+
+func (s *proofSet) add(proof [][]byte) {
+	if s.seen == nil {
+		s.seen = make(map[string]struct{}, len(proof))
+	}
+
+	for _, rlpEnc := range proof {
+		if _, ok := s.seen[string(rlpEnc)]; !ok {
+			s.seen[string(rlpEnc)] = struct{}{}
+			s.nodes = append(s.nodes, rlpEnc)
+		}
+	}
+}
+
+// proofNodes indexes the RLP encoded nodes of a proof by their Keccak256 hash.
+// Nodes are only decoded once, when they are first resolved.
+type proofNodes struct {
+	encoded map[string][]byte
+	decoded map[string]node.Node
+}
+
+func newProofNodes(proof [][]byte) *proofNodes {
+	nodes := &proofNodes{
+		encoded: make(map[string][]byte, len(proof)),
+		decoded: make(map[string]node.Node, len(proof)),
+	}
+
+	for _, rlpEnc := range proof {
+		nodes.encoded[string(crypto.Keccak256(rlpEnc))] = rlpEnc
+	}
+
+	return nodes
+}
+
+// verify walks the proof from the root along the path and returns the value at the end.
+func (p *proofNodes) verify(root, path []byte) ([]byte, error) {
+	if bytes.Equal(root, emptyRoot) {
+		return nil, ErrNotFound
+	}
+
+	var (
+		current node.Node = node.Hashed(root)
+		depth   int
+		err     error
+	)
+
+	for {
+		switch n := current.(type) {
+		case nil:
+			return nil, ErrNotFound
+
+		case *node.Branch:
+			if depth >= len(path) {
+				return nil, &ProofError{Path: path[:depth], Err: ErrNodeType}
+			}
+
+			current = n.Children[path[depth]]
+			depth += 1
+
+		case *node.Extension:
+			keyLen := len(n.Key)
+
+			if len(path)-depth < keyLen || !bytes.Equal(path[depth:depth+keyLen], n.Key) {
+				return nil, ErrNotFound
+			}
+
+			current = n.Next
+			depth += keyLen
+
+		case node.Leaf:
+			return n, nil
+
+		case node.Hashed:
+			if current, err = p.resolve(path[:depth], n); err != nil {
+				return nil, err
+			}
+
+		default:
+			return nil, &ProofError{Path: path[:depth], Err: fmt.Errorf("%w: %T unexpected", ErrNodeType, n)}
+		}
+	}
+}
+
+// resolve returns the decoded node of the proof referenced by the hash.
+// The node is only found if its Keccak256 hash matches the reference from its parent.
+func (p *proofNodes) resolve(path []byte, hashed node.Hashed) (node.Node, error) {
+	if n, ok := p.decoded[string(hashed)]; ok {
+		return n, nil
+	}
+
+	n, err := p.decode(path, hashed)
+	if err != nil {
+		return nil, err
+	}
+
+	p.decoded[string(hashed)] = n
+
+	return n, nil
+}
+
+// decode returns a new decoded node of the proof referenced by the hash.
+func (p *proofNodes) decode(path []byte, hashed node.Hashed) (node.Node, error) {
+	rlpEnc, ok := p.encoded[string(hashed)]
+	if !ok {
+		return nil, &ProofError{Path: path, Hash: hashed, Err: ErrProofMissingNode}
+	}
+
+	n, err := node.Decode(rlpEnc, hashed)
+	if err != nil {
+		return nil, &ProofError{Path: path, Hash: hashed, Err: fmt.Errorf("%w: %v", ErrProofDecode, err)}
+	}
+
+	return n, nil
+}
@@ -0,0 +1,147 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.0xjac.com/tfmpt/crypto"
+)
+
+func TestVerifyProof(t *testing.T) {
+	ethMPT := ethTrieFixture(t)
+	root := ethMPT.Hash()
+
+	for _, test := range nodes {
+		t.Run(fmt.Sprintf("VerifyProof[k=%s]", test.key), func(t *testing.T) {
+			proof := ethProofFixture(t, test.key)
+
+			actual, err := VerifyProof(root[:], test.key, proof)
+
+			assertPresent(t, test.key, actual, test.val, err)
+		})
+	}
+
+	for _, test := range missings {
+		t.Run(fmt.Sprintf("VerifyProof[missing_k=%s]", test), func(t *testing.T) {
+			proof := ethProofFixture(t, test)
+
+			actual, err := VerifyProof(root[:], test, proof)
+
+			assertMissing(t, test, actual, err)
+		})
+	}
+}
+
+func TestVerifyAbsence(t *testing.T) {
+	ethMPT := ethTrieFixture(t)
+	root := ethMPT.Hash()
+
+	for _, test := range missings {
+		t.Run(fmt.Sprintf("VerifyAbsence[missing_k=%s]", test), func(t *testing.T) {
+			if err := VerifyAbsence(root[:], test, ethProofFixture(t, test)); err != nil {
+				t.Errorf("Expected key=%s to be proven absent, got err=%s", test, err)
+			}
+		})
+	}
+
+	for _, test := range nodes {
+		t.Run(fmt.Sprintf("VerifyAbsence[k=%s]", test.key), func(t *testing.T) {
+			if err := VerifyAbsence(root[:], test.key, ethProofFixture(t, test.key)); !errors.Is(err, ErrKeyExists) {
+				t.Errorf("Expected key=%s to exist, got err=%s", test.key, err)
+			}
+		})
+	}
+
+	t.Run("VerifyAbsence[empty_trie]", func(t *testing.T) {
+		key := []byte("<missing>")
+
+		proof, err := NewEmptyTrie(nil).Proof(key)
+		if err != nil {
+			t.Errorf("Expected an absence proof for key=%s, got err=%s", key, err)
+		}
+
+		if err = VerifyAbsence(emptyRoot, key, proof); err != nil {
+			t.Errorf("Expected key=%s to be proven absent, got err=%s", key, err)
+		}
+	})
+
+	t.Run("VerifyAbsence[truncated]", func(t *testing.T) {
+		key := []byte("dogs")
+		proof := ethProofFixture(t, key)
+
+		if err := VerifyAbsence(root[:], key, proof[1:]); !errors.Is(err, ErrInvalidProof) {
+			t.Errorf("Expected proof for key=%s to be invalid, got err=%s", key, err)
+		}
+	})
+}
+
+func TestVerifyProofInvalid(t *testing.T) {
+	ethMPT := ethTrieFixture(t)
+	root := ethMPT.Hash()
+	key := []byte("doge")
+
+	t.Run("VerifyProof[empty]", func(t *testing.T) {
+		assertInvalidProof(t, key, root[:], nil, ErrProofMissingNode)
+	})
+
+	t.Run("VerifyProof[wrong_root]", func(t *testing.T) {
+		assertInvalidProof(t, key, crypto.Keccak256(key), ethProofFixture(t, key), ErrProofMissingNode)
+	})
+
+	t.Run("VerifyProof[truncated]", func(t *testing.T) {
+		proof := ethProofFixture(t, key)
+
+		for i := range proof {
+			truncated := append(append([][]byte{}, proof[:i]...), proof[i+1:]...)
+
+			assertInvalidProof(t, key, root[:], truncated, ErrProofMissingNode)
+		}
+	})
+
+	t.Run("VerifyProof[tampered]", func(t *testing.T) {
+		proof := ethProofFixture(t, key)
+
+		for i := range proof {
+			tampered := append([][]byte{}, proof...)
+			tampered[i] = append([]byte{}, proof[i]...)
+			tampered[i][len(tampered[i])-1] ^= 0xff
+
+			assertInvalidProof(t, key, root[:], tampered, ErrProofMissingNode)
+		}
+	})
+}
+
+func assertInvalidProof(t *testing.T, key, root []byte, proof [][]byte, reason error) {
+	t.Helper()
+
+	val, err := VerifyProof(root, key, proof)
+
+	var proofErr *ProofError
+	if !errors.Is(err, ErrInvalidProof) || !errors.As(err, &proofErr) || !errors.Is(err, reason) {
+		t.Errorf("Expected proof for key=%s to be invalid (%s), got err=%s", key, reason, err)
+	}
+
+	if val != nil {
+		t.Errorf("Expected no value for key=%s, got val=%s", key, val)
+	}
+}
+
+// ethProofFixture returns the proof of a key from the official go-ethereum implementation.
+func ethProofFixture(t *testing.T, key []byte) [][]byte {
+	t.Helper()
+
+	expected := newMockEthProofDB(0)
+	if err := ethTrieFixture(t).Prove(key, expected); err != nil {
+		t.Fatalf("Expected a proof for key=%s, got err=%s", key, err)
+	}
+
+	proof := make([][]byte, 0, len(expected))
+	for _, rlpEnc := range expected {
+		proof = append(proof, rlpEnc)
+	}
+
+	return proof
+}
@@ -0,0 +1,280 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"go.0xjac.com/tfmpt/encoding"
+	"go.0xjac.com/tfmpt/node"
+)
+
+var ErrInvalidRange = errors.New("invalid range")
+
+// RangeProof holds the [key, value] pairs of a range of the trie, in order,
+// with the RLP encoded nodes proving both edges of the range.
+type RangeProof struct {
+	Keys   [][]byte
+	Values [][]byte
+	Proof  [][]byte
+}
+
+// ProveRange returns all the [key, value] pairs from start to end (both included),
+// and the Merkle-proofs of start and end, whether they are in the trie or not.
+func (t *Trie) ProveRange(start, end []byte) (*RangeProof, error) {
+	first, last := encoding.ToHex(start), encoding.ToHex(end)
+	if encoding.CompareHex(first, last) > 0 {
+		return nil, fmt.Errorf("%w: start %x is after end %x", ErrInvalidRange, start, end)
+	}
+
+	rangeProof := &RangeProof{}
+
+	it := t.Iterator(start)
+	for it.Next() && bytes.Compare(it.Key(), end) <= 0 {
+		rangeProof.Keys = append(rangeProof.Keys, it.Key())
+		rangeProof.Values = append(rangeProof.Values, it.Value())
+	}
+
+	if it.Err() != nil {
+		return nil, it.Err()
+	}
+
+	var edges proofSet
+	for _, key := range [][]byte{start, end} {
+		proof, err := t.Proof(key)
+		if err != nil {
+			return nil, err
+		}
+
+		edges.add(proof)
+	}
+
+	rangeProof.Proof = edges.nodes
+
+	return rangeProof, nil
+}
+
+// VerifyRangeProof checks that the keys and values are exactly all the [key, value] pairs
+// from firstKey to lastKey (both included) in the trie with the given root hash.
+// The proof is the list of RLP encoded nodes proving both edges of the range,
+// such as the one returned by Trie.ProveRange.
+//
+// The partial trie along both edges is rebuilt from the proof. Every node between the edges
+// is removed and replaced by the given [key, value] pairs. The range is only valid if
+// the root hash of the resulting trie matches, which means no pair was omitted or altered.
+func VerifyRangeProof(root, firstKey, lastKey []byte, keys, values [][]byte, proof [][]byte) error {
+	first, last := encoding.ToHex(firstKey), encoding.ToHex(lastKey)
+	if err := checkRange(first, last, keys, values); err != nil {
+		return err
+	}
+
+	if bytes.Equal(root, emptyRoot) {
+		if len(keys) > 0 {
+			return fmt.Errorf("%w: %d keys in an empty trie", ErrInvalidRange, len(keys))
+		}
+
+		return nil
+	}
+
+	nodes := newProofNodes(proof)
+
+	// Rebuild the partial trie along both edges.
+	partial, err := nodes.resolvePath(node.Hashed(root), first, 0)
+	if err != nil {
+		return err
+	}
+
+	if partial, err = nodes.resolvePath(partial, last, 0); err != nil {
+		return err
+	}
+
+	// Replace all the nodes within the range by the keys and values.
+	if partial, err = removeRange(partial, nil, first, last); err != nil {
+		return err
+	}
+
+	mpt := NewEmptyTrie(nil)
+	mpt.root = partial
+
+	for i, key := range keys {
+		if err = mpt.Update(key, values[i]); err != nil {
+			var missing *MissingNodeError
+			if errors.As(err, &missing) {
+				return &ProofError{Path: missing.Path, Hash: missing.Hash, Err: ErrProofMissingNode}
+			}
+
+			return err
+		}
+	}
+
+	if hash := mpt.Hash(); !bytes.Equal(hash, root) {
+		return &ProofError{Hash: root, Err: fmt.Errorf("%w: root mismatch, got %064x", ErrInvalidRange, hash)}
+	}
+
+	return nil
+}
+
+// checkRange ensures the keys are sorted, unique, within the range and have non-empty values.
+func checkRange(first, last []byte, keys, values [][]byte) error {
+	if len(keys) != len(values) {
+		return fmt.Errorf("%w: %d keys for %d values", ErrInvalidRange, len(keys), len(values))
+	}
+
+	if encoding.CompareHex(first, last) > 0 {
+		return fmt.Errorf("%w: first key is after last key", ErrInvalidRange)
+	}
+
+	var previous []byte
+	for i, key := range keys {
+		switch {
+		case len(values[i]) == 0:
+			return fmt.Errorf("%w: empty value for key %x", ErrInvalidRange, key)
+		case i > 0 && bytes.Compare(previous, key) >= 0:
+			return fmt.Errorf("%w: keys are not sorted at key %x", ErrInvalidRange, key)
+		case rangePosition(encoding.ToHex(key), first, last) != rangeInside:
+			return fmt.Errorf("%w: key %x is out of range", ErrInvalidRange, key)
+		}
+
+		previous = key
+	}
+
+	return nil
+}
+
+// resolvePath replaces the hashed nodes along the path with their decoded node from the proof.
+// Nodes are decoded anew since they are modified when removing the range.
+func (p *proofNodes) resolvePath(n node.Node, path []byte, depth int) (node.Node, error) {
+	switch current := n.(type) {
+	case node.Hashed:
+		resolved, err := p.decode(path[:depth], current)
+		if err != nil {
+			return nil, err
+		}
+
+		return p.resolvePath(resolved, path, depth)
+
+	case *node.Branch:
+		if depth >= len(path) {
+			return current, nil
+		}
+
+		child, err := p.resolvePath(current.Children[path[depth]], path, depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+		current.Children[path[depth]] = child
+
+		return current, nil
+
+	case *node.Extension:
+		keyLen := len(current.Key)
+		if len(path)-depth < keyLen || !bytes.Equal(path[depth:depth+keyLen], current.Key) {
+			return current, nil // The path diverges from the extension's key.
+		}
+
+		next, err := p.resolvePath(current.Next, path, depth+keyLen)
+		if err != nil {
+			return nil, err
+		}
+
+		current.Next = next
+
+		return current, nil
+
+	default:
+		return current, nil
+	}
+}
+
+// removeRange removes all the nodes of the partial trie at the path which are within the range.
+func removeRange(n node.Node, path, first, last []byte) (node.Node, error) {
+	switch current := n.(type) {
+	case nil:
+		return nil, nil
+
+	case node.Leaf:
+		if rangePosition(path, first, last) == rangeInside {
+			return nil, nil
+		}
+
+		return current, nil
+
+	case *node.Branch:
+		switch rangePosition(path, first, last) {
+		case rangeOutside:
+			return current, nil
+		case rangeInside:
+			return nil, nil
+		}
+
+		current.Cache = nil
+		for i := range current.Children {
+			child, err := removeRange(current.Children[i], append(path[:len(path):len(path)], byte(i)), first, last)
+			if err != nil {
+				return nil, err
+			}
+
+			current.Children[i] = child
+		}
+
+		return current, nil
+
+	case *node.Extension:
+		nextPath := append(path[:len(path):len(path)], current.Key...)
+
+		switch rangePosition(nextPath, first, last) {
+		case rangeOutside:
+			return current, nil
+		case rangeInside:
+			return nil, nil
+		}
+
+		next, err := removeRange(current.Next, nextPath, first, last)
+		if err != nil {
+			return nil, err
+		}
+
+		current.Cache = nil
+		current.Next = next
+
+		return current, nil
+
+	case node.Hashed:
+		switch rangePosition(path, first, last) {
+		case rangeOutside:
+			return current, nil
+		case rangeInside:
+			return nil, nil
+		}
+
+		// Nodes on the edges of the range must be resolved from the proof.
+		return nil, &ProofError{Path: path, Hash: current, Err: ErrProofMissingNode}
+
+	default:
+		return nil, fmt.Errorf("%w: %T unknown", ErrNodeType, current)
+	}
+}
+
+const (
+	rangeOutside = iota // All the keys under the path are out of the range.
+	rangeInside         // All the keys under the path are in the range.
+	rangeEdge           // The path is a prefix of the first or last key of the range.
+)
+
+// rangePosition indicates where the keys under a hex-encoded path are relative to the range.
+func rangePosition(path, first, last []byte) int {
+	afterFirst := encoding.CompareHex(path, first[:min(len(path), len(first))])
+	beforeLast := encoding.CompareHex(path, last[:min(len(path), len(last))])
+
+	switch {
+	case afterFirst < 0 || beforeLast > 0:
+		return rangeOutside
+	case (afterFirst > 0 && beforeLast < 0) || encoding.HexKeyHasTerm(path):
+		return rangeInside
+	default:
+		return rangeEdge
+	}
+}
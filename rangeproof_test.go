@@ -0,0 +1,110 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestTrieProveRange(t *testing.T) {
+	bounds := [][]byte{
+		[]byte(""), []byte("d"), []byte("do"), []byte("dog"), []byte("doge"), []byte("dogs"),
+		[]byte("horse"), []byte("z"),
+	}
+
+	for _, commit := range []bool{false, true} {
+		for i, start := range bounds {
+			for _, end := range bounds[i:] {
+				t.Run(fmt.Sprintf("ProveRange[%s,%s]%s", start, end, suffix(t, commit)), func(t *testing.T) {
+					t.Parallel()
+
+					mpt, cleanup := trieSetup(t, commit)
+					defer cleanup()
+
+					rangeProof, err := mpt.(*Trie).ProveRange(start, end)
+					if err != nil {
+						t.Fatalf("Expected a range proof, got err=%s", err)
+					}
+
+					var keys, values [][]byte
+					for _, node := range sortedNodes() {
+						if bytes.Compare(node.key, start) >= 0 && bytes.Compare(node.key, end) <= 0 {
+							keys, values = append(keys, node.key), append(values, node.val)
+						}
+					}
+
+					if !reflect.DeepEqual(rangeProof.Keys, keys) || !reflect.DeepEqual(rangeProof.Values, values) {
+						t.Errorf("Expected keys=%s values=%s, got keys=%s values=%s",
+							keys, values, rangeProof.Keys, rangeProof.Values)
+					}
+
+					err = VerifyRangeProof(mpt.Hash(), start, end, rangeProof.Keys, rangeProof.Values, rangeProof.Proof)
+					if err != nil {
+						t.Errorf("Expected range proof to be valid, got err=%s", err)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestVerifyRangeProofInvalid(t *testing.T) {
+	mpt, root, pairs := randomTrieFixture(t, 200)
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 50; i++ {
+		start, end := pairs[r.Intn(len(pairs)/2)].key, pairs[len(pairs)/2+r.Intn(len(pairs)/2)].key
+
+		rangeProof, err := mpt.ProveRange(start, end)
+		if err != nil {
+			t.Fatalf("Expected a range proof, got err=%s", err)
+		}
+
+		keys, values, proof := rangeProof.Keys, rangeProof.Values, rangeProof.Proof
+		if err = VerifyRangeProof(root, start, end, keys, values, proof); err != nil {
+			t.Fatalf("Expected range proof [%x,%x] to be valid, got err=%s", start, end, err)
+		}
+
+		omitted := r.Intn(len(keys))
+		tampered := append([]byte{}, values[omitted]...)
+		tampered[0] ^= 0xff
+
+		for name, test := range map[string]struct {
+			keys, values, proof [][]byte
+		}{
+			"omitted_key": {
+				keys:   append(append([][]byte{}, keys[:omitted]...), keys[omitted+1:]...),
+				values: append(append([][]byte{}, values[:omitted]...), values[omitted+1:]...),
+				proof:  proof,
+			},
+			"tampered_value": {
+				keys:   keys,
+				values: append(append(append([][]byte{}, values[:omitted]...), tampered), values[omitted+1:]...),
+				proof:  proof,
+			},
+			"missing_edge": {
+				keys:   keys,
+				values: values,
+				proof:  proof[1:],
+			},
+		} {
+			err = VerifyRangeProof(root, start, end, test.keys, test.values, test.proof)
+			if !errors.Is(err, ErrInvalidProof) {
+				t.Errorf("Expected %s range proof [%x,%x] to be invalid, got err=%s", name, start, end, err)
+			}
+		}
+
+		if err = VerifyRangeProof(root, start, end, keys[1:], values, proof); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("Expected mismatched keys and values to be invalid, got err=%s", err)
+		}
+
+		if err = VerifyRangeProof(root, end, start, keys, values, proof); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("Expected reversed range to be invalid, got err=%s", err)
+		}
+	}
+}
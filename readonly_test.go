@@ -0,0 +1,111 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"go.0xjac.com/tfmpt/store"
+)
+
+func TestTrieReadOnly(t *testing.T) {
+	db, root, pairs := committedTrieFixture(t, 100)
+	mpt := LoadTrie(db, root, WithReadOnly())
+
+	if err := mpt.Update(pairs[0].key, []byte("<new_val>")); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected update to fail with err=%s, got err=%s", ErrReadOnly, err)
+	}
+
+	if err := mpt.Del(pairs[0].key); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected delete to fail with err=%s, got err=%s", ErrReadOnly, err)
+	}
+
+	if _, err := mpt.Commit(); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected commit to fail with err=%s, got err=%s", ErrReadOnly, err)
+	}
+
+	if err := mpt.Rollback(root); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected rollback to fail with err=%s, got err=%s", ErrReadOnly, err)
+	}
+
+	func() {
+		defer func() {
+			if err, _ := recover().(error); !errors.Is(err, ErrReadOnly) {
+				t.Errorf("Expected put to panic with err=%s, got err=%s", ErrReadOnly, err)
+			}
+		}()
+
+		mpt.Put(pairs[0].key, []byte("<new_val>"))
+	}()
+
+	val, err := mpt.Get(pairs[0].key)
+	assertPresent(t, pairs[0].key, val, pairs[0].val, err)
+}
+
+func TestTrieReadOnlyConcurrent(t *testing.T) {
+	dbPath := t.TempDir()
+
+	db, err := store.NewLevelDB(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mpt, _, pairs := randomTrieFixture(t, 300)
+	mpt.db = db
+
+	root, err := mpt.Commit()
+	if err != nil {
+		t.Fatalf("Expected trie to be committed, got err=%s", err)
+	}
+
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if db, err = store.NewLevelDBReadOnly(dbPath); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err = db.Put([]byte("<key>"), []byte("<val>")); err == nil {
+		t.Errorf("Expected write to the read-only store to fail")
+	}
+
+	mpt = LoadTrie(db, root, WithReadOnly(), WithCache(64))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func(offset int) {
+			defer wg.Done()
+
+			for j := offset; j < len(pairs); j += 8 {
+				val, err := mpt.Get(pairs[j].key)
+				assertPresent(t, pairs[j].key, val, pairs[j].val, err)
+
+				proof, err := mpt.Proof(pairs[j].key)
+				if err != nil {
+					t.Errorf("Expected a proof for key=%x, got err=%s", pairs[j].key, err)
+				} else if val, err = VerifyProof(root, pairs[j].key, proof); err != nil {
+					t.Errorf("Expected proof for key=%x to be valid, got err=%s", pairs[j].key, err)
+				}
+			}
+
+			assertIterator(t, mpt.Iterator(nil), pairs)
+
+			it := mpt.NodeIterator()
+			for it.Next(true) {
+				_ = it.Hash()
+			}
+
+			if it.Err() != nil {
+				t.Errorf("Expected node iteration to succeed, got err=%s", it.Err())
+			}
+		}(i)
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package store_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"go.0xjac.com/tfmpt/store"
+)
+
+func ExampleMemoryDB() {
+	db := store.NewMemoryDB()
+	_ = db.Put([]byte("dog"), []byte("puppy"))
+
+	snapshot := db.Copy()
+
+	batch := db.NewBatch()
+	_ = batch.Put([]byte("horse"), []byte("stallion"))
+	_ = batch.Delete([]byte("dog"))
+	_ = batch.Write()
+
+	_, err := db.Get([]byte("dog"))
+	fmt.Println(errors.Is(err, store.ErrNotFound), db.Len(), db.Size())
+
+	value, _ := snapshot.Get([]byte("dog"))
+	fmt.Printf("%s %d %d\n", value, snapshot.Len(), snapshot.Size())
+	// Output:
+	// true 1 13
+	// puppy 1 8
+}
+
+func ExamplePebble() {
+	dbPath, err := os.MkdirTemp("", "tfmpt-example-pebble-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dbPath)
+
+	db, err := store.NewPebble(dbPath)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	batch := db.NewBatch()
+	for _, key := range []string{"do", "dog", "doge", "horse"} {
+		_ = batch.Put([]byte(key), []byte("<"+key+">"))
+	}
+
+	if err = batch.Write(); err != nil {
+		panic(err)
+	}
+
+	it := db.NewIterator([]byte("do"), []byte("g"), nil)
+	defer it.Release()
+
+	for it.Next() {
+		fmt.Printf("%s=%s\n", it.Key(), it.Value())
+	}
+	// Output:
+	// dog=<dog>
+	// doge=<doge>
+}
+
+func ExampleIteratee() {
+	dbPath, err := os.MkdirTemp("", "tfmpt-example-leveldb-*")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dbPath)
+
+	levelDB, err := store.NewLevelDB(dbPath)
+	if err != nil {
+		panic(err)
+	}
+	defer levelDB.Close()
+
+	for _, db := range []interface {
+		store.DB
+		store.Iteratee
+	}{levelDB, store.NewMemoryDB()} {
+		for _, key := range []string{"do", "dog", "doge", "dot", "horse"} {
+			_ = db.Put([]byte(key), []byte("<"+key+">"))
+		}
+
+		// Iterate over the keys with the prefix "do", from "dog" to "dot" (excluded).
+		it := db.NewIterator([]byte("do"), []byte("g"), []byte("t"))
+		for it.Next() {
+			fmt.Printf("%s=%s ", it.Key(), it.Value())
+		}
+
+		fmt.Println(it.Error())
+		it.Release()
+	}
+	// Output:
+	// dog=<dog> doge=<doge> <nil>
+	// dog=<dog> doge=<doge> <nil>
+}
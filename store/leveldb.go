@@ -3,17 +3,30 @@
 package store
 
 import (
+	"errors"
+
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
-var _ DB = (*LevelDB)(nil)
+var (
+	_ DB       = (*LevelDB)(nil)
+	_ Batcher  = (*LevelDB)(nil)
+	_ Iteratee = (*LevelDB)(nil)
+)
 
 type LevelDB struct {
 	*leveldb.DB
 }
 
 func (l *LevelDB) Get(key []byte) ([]byte, error) {
-	return l.DB.Get(key, nil)
+	value, err := l.DB.Get(key, nil)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+
+	return value, err
 }
 
 func (l *LevelDB) Put(key, value []byte) error {
@@ -24,8 +37,43 @@ func (l *LevelDB) Delete(key []byte) error {
 	return l.DB.Delete(key, nil)
 }
 
+func (l *LevelDB) NewBatch() Batch {
+	return &levelDBBatch{db: l.DB, batch: new(leveldb.Batch)}
+}
+
+func (l *LevelDB) NewIterator(prefix, start, end []byte) Iterator {
+	lower, upper := iteratorRange(prefix, start, end)
+	return l.DB.NewIterator(&util.Range{Start: lower, Limit: upper}, nil)
+}
+
 func NewLevelDB(dbPath string) (*LevelDB, error) {
 	db, err := leveldb.OpenFile(dbPath, nil)
 
 	return &LevelDB{db}, err
 }
+
+// NewLevelDBReadOnly opens an existing LevelDB in read-only mode, where every write fails.
+func NewLevelDBReadOnly(dbPath string) (*LevelDB, error) {
+	db, err := leveldb.OpenFile(dbPath, &opt.Options{ReadOnly: true, ErrorIfMissing: true})
+
+	return &LevelDB{db}, err
+}
+
+type levelDBBatch struct {
+	db    *leveldb.DB
+	batch *leveldb.Batch
+}
+
+func (b *levelDBBatch) Put(key, value []byte) error {
+	b.batch.Put(key, value)
+	return nil
+}
+
+func (b *levelDBBatch) Delete(key []byte) error {
+	b.batch.Delete(key)
+	return nil
+}
+
+func (b *levelDBBatch) Write() error {
+	return b.db.Write(b.batch, nil)
+}
@@ -0,0 +1,222 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package store
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+)
+
+var (
+	_ DB       = (*MemoryDB)(nil)
+	_ Batcher  = (*MemoryDB)(nil)
+	_ Iteratee = (*MemoryDB)(nil)
+)
+
+// MemoryDB is an in-memory store, safe for concurrent use.
+type MemoryDB struct {
+	mu sync.RWMutex
+	db map[string][]byte
+}
+
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{db: make(map[string][]byte)}
+}
+
+func (m *MemoryDB) Get(key []byte) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.db == nil {
+		return nil, ErrClosed
+	}
+
+	value, ok := m.db[string(key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return append([]byte{}, value...), nil
+}
+
+func (m *MemoryDB) Put(key, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.db == nil {
+		return ErrClosed
+	}
+
+	m.db[string(key)] = append([]byte{}, value...)
+
+	return nil
+}
+
+func (m *MemoryDB) Delete(key []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.db == nil {
+		return ErrClosed
+	}
+
+	delete(m.db, string(key))
+
+	return nil
+}
+
+// Close releases the content of the store. Any later operation fails with ErrClosed.
+func (m *MemoryDB) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.db = nil
+
+	return nil
+}
+
+func (m *MemoryDB) NewBatch() Batch {
+	return &memoryBatch{db: m}
+}
+
+// NewIterator returns an iterator over a point-in-time snapshot of the keys in the range.
+func (m *MemoryDB) NewIterator(prefix, start, end []byte) Iterator {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.db == nil {
+		return &memoryIterator{err: ErrClosed}
+	}
+
+	lower, upper := iteratorRange(prefix, start, end)
+	it := &memoryIterator{index: -1}
+
+	for key := range m.db {
+		if bytes.Compare([]byte(key), lower) >= 0 && (upper == nil || bytes.Compare([]byte(key), upper) < 0) {
+			it.keys = append(it.keys, key)
+		}
+	}
+
+	sort.Strings(it.keys)
+
+	it.values = make([][]byte, len(it.keys))
+	for i, key := range it.keys {
+		it.values[i] = m.db[key] // Values are never modified in place.
+	}
+
+	return it
+}
+
+// Copy returns an independent copy of the content of the store at this point in time.
+func (m *MemoryDB) Copy() *MemoryDB {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	cp := &MemoryDB{db: make(map[string][]byte, len(m.db))}
+	for key, value := range m.db {
+		cp.db[key] = value // Values are never modified in place.
+	}
+
+	return cp
+}
+
+// Len returns the number of keys in the store.
+func (m *MemoryDB) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.db)
+}
+
+// Size returns the total size in bytes of the keys and values in the store.
+func (m *MemoryDB) Size() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	size := 0
+	for key, value := range m.db {
+		size += len(key) + len(value)
+	}
+
+	return size
+}
+
+type memoryWrite struct {
+	key    string
+	value  []byte
+	delete bool
+}
+
+type memoryBatch struct {
+	db     *MemoryDB
+	writes []memoryWrite
+}
+
+func (b *memoryBatch) Put(key, value []byte) error {
+	b.writes = append(b.writes, memoryWrite{key: string(key), value: append([]byte{}, value...)})
+	return nil
+}
+
+func (b *memoryBatch) Delete(key []byte) error {
+	b.writes = append(b.writes, memoryWrite{key: string(key), delete: true})
+	return nil
+}
+
+func (b *memoryBatch) Write() error {
+	b.db.mu.Lock()
+	defer b.db.mu.Unlock()
+
+	if b.db.db == nil {
+		return ErrClosed
+	}
+
+	for _, write := range b.writes {
+		if write.delete {
+			delete(b.db.db, write.key)
+		} else {
+			b.db.db[write.key] = write.value
+		}
+	}
+
+	return nil
+}
+
+type memoryIterator struct {
+	keys   []string
+	values [][]byte
+	index  int
+	err    error
+}
+
+func (it *memoryIterator) Next() bool {
+	if it.index >= len(it.keys) {
+		return false
+	}
+
+	it.index += 1
+
+	return it.index < len(it.keys)
+}
+
+func (it *memoryIterator) Key() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+
+	return []byte(it.keys[it.index])
+}
+
+func (it *memoryIterator) Value() []byte {
+	if it.index < 0 || it.index >= len(it.values) {
+		return nil
+	}
+
+	return it.values[it.index]
+}
+
+func (it *memoryIterator) Error() error { return it.err }
+
+func (it *memoryIterator) Release() {
+	it.keys, it.values = nil, nil
+}
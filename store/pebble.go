@@ -0,0 +1,133 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package store
+
+import (
+	"errors"
+
+	"github.com/cockroachdb/pebble"
+)
+
+var (
+	_ DB       = (*Pebble)(nil)
+	_ Batcher  = (*Pebble)(nil)
+	_ Iteratee = (*Pebble)(nil)
+)
+
+type Pebble struct {
+	*pebble.DB
+}
+
+func (p *Pebble) Get(key []byte) ([]byte, error) {
+	value, closer, err := p.DB.Get(key)
+	if errors.Is(err, pebble.ErrNotFound) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	defer closer.Close()
+
+	return append([]byte{}, value...), nil // The value is only valid until the closer is closed.
+}
+
+func (p *Pebble) Put(key, value []byte) error {
+	return p.DB.Set(key, value, pebble.NoSync)
+}
+
+func (p *Pebble) Delete(key []byte) error {
+	return p.DB.Delete(key, pebble.NoSync)
+}
+
+func (p *Pebble) NewBatch() Batch {
+	return &pebbleBatch{batch: p.DB.NewBatch()}
+}
+
+func (p *Pebble) NewIterator(prefix, start, end []byte) Iterator {
+	lower, upper := iteratorRange(prefix, start, end)
+	iter, err := p.DB.NewIter(&pebble.IterOptions{LowerBound: lower, UpperBound: upper})
+
+	return &pebbleIterator{iter: iter, err: err}
+}
+
+func NewPebble(dbPath string) (*Pebble, error) {
+	db, err := pebble.Open(dbPath, &pebble.Options{})
+
+	return &Pebble{db}, err
+}
+
+// NewPebbleReadOnly opens an existing Pebble store in read-only mode, where every write fails.
+func NewPebbleReadOnly(dbPath string) (*Pebble, error) {
+	db, err := pebble.Open(dbPath, &pebble.Options{ReadOnly: true, ErrorIfNotExists: true})
+
+	return &Pebble{db}, err
+}
+
+type pebbleBatch struct {
+	batch *pebble.Batch
+}
+
+func (b *pebbleBatch) Put(key, value []byte) error {
+	return b.batch.Set(key, value, nil)
+}
+
+func (b *pebbleBatch) Delete(key []byte) error {
+	return b.batch.Delete(key, nil)
+}
+
+func (b *pebbleBatch) Write() error {
+	return b.batch.Commit(pebble.NoSync)
+}
+
+type pebbleIterator struct {
+	iter  *pebble.Iterator
+	moved bool
+	err   error
+}
+
+func (it *pebbleIterator) Next() bool {
+	if it.iter == nil {
+		return false
+	}
+
+	if !it.moved {
+		it.moved = true
+		return it.iter.First()
+	}
+
+	return it.iter.Next()
+}
+
+func (it *pebbleIterator) Key() []byte {
+	if it.iter == nil || !it.iter.Valid() {
+		return nil
+	}
+
+	return it.iter.Key()
+}
+
+func (it *pebbleIterator) Value() []byte {
+	if it.iter == nil || !it.iter.Valid() {
+		return nil
+	}
+
+	return it.iter.Value()
+}
+
+func (it *pebbleIterator) Error() error {
+	if it.err != nil || it.iter == nil {
+		return it.err
+	}
+
+	return it.iter.Error()
+}
+
+func (it *pebbleIterator) Release() {
+	if it.iter != nil {
+		if err := it.iter.Close(); err != nil && it.err == nil {
+			it.err = err
+		}
+
+		it.iter = nil
+	}
+}
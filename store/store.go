@@ -2,9 +2,78 @@
 
 package store
 
+import "errors"
+
+var (
+	// ErrNotFound is returned by Get when the key is not in the store.
+	ErrNotFound = errors.New("store: not found")
+
+	// ErrClosed is returned when the store is used after it is closed.
+	ErrClosed = errors.New("store: closed")
+)
+
 type DB interface {
+	Writer
 	Get(key []byte) ([]byte, error)
+	Close() error
+}
+
+// Writer writes to a store.
+type Writer interface {
 	Put(key, value []byte) error
 	Delete(key []byte) error
-	Close() error
+}
+
+// Batch buffers writes until they are written to the store atomically by Write.
+type Batch interface {
+	Writer
+	Write() error
+}
+
+// Batcher is implemented by the stores which support atomic batches of writes.
+type Batcher interface {
+	NewBatch() Batch
+}
+
+// Iterator iterates over the [key, value] pairs of a store in the lexicographic order of the keys.
+// The key and value must not be modified and are only valid until the next call to Next.
+type Iterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Error() error
+	Release()
+}
+
+// Iteratee is implemented by the stores which can iterate over their keys.
+type Iteratee interface {
+	// NewIterator returns an iterator over the keys with the prefix, from prefix+start (included)
+	// to prefix+end (excluded), or to the last key with the prefix if end is nil.
+	// The iterator must be released once done with.
+	NewIterator(prefix, start, end []byte) Iterator
+}
+
+// iteratorRange returns the lowest key (included) and the highest key (excluded) to iterate over.
+// The highest key is nil if there is no upper bound.
+func iteratorRange(prefix, start, end []byte) ([]byte, []byte) {
+	lower := append(append([]byte{}, prefix...), start...)
+	if end != nil {
+		return lower, append(append([]byte{}, prefix...), end...)
+	}
+
+	return lower, prefixEnd(prefix)
+}
+
+// prefixEnd returns the smallest key after all the keys with the prefix, or nil if there is none.
+func prefixEnd(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] < 0xff {
+			end := append([]byte{}, prefix[:i+1]...)
+			end[i] += 1
+
+			return end
+		}
+	}
+
+	return nil
 }
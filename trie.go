@@ -6,8 +6,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-
-	"github.com/ethereum/go-ethereum/rlp"
+	"sync/atomic"
 
 	"go.0xjac.com/tfmpt/crypto"
 	"go.0xjac.com/tfmpt/encoding"
@@ -18,6 +17,9 @@ import (
 var (
 	ErrNotFound = errors.New("not found")
 	ErrNodeType = errors.New("bad node type")
+	ErrNoDB     = errors.New("db is not set")
+	ErrScheme   = errors.New("unsupported scheme")
+	ErrReadOnly = errors.New("trie is read-only")
 
 	// emptyRoot is the precomputed hash of an empty MPT.
 	// It is equivalent to keccak256(rlp(byte(0)).
@@ -35,25 +37,55 @@ type iTrie interface {
 	Get(key []byte) ([]byte, error)
 
 	// Put inserts the [key, value] node in the trie
+	// and panics if the trie cannot be updated.
 	Put(key []byte, value []byte)
 
+	// Update inserts the [key, value] node in the trie
+	// returns an error if the trie cannot be updated.
+	Update(key []byte, value []byte) error
+
 	// Del removes a node from the trie
 	// returns an error if not found.
 	Del(key []byte) error
 
+	// Hash returns the root hash of the trie
+	// without saving anything to persistent storage.
+	Hash() []byte
+
 	// Commit saves the trie in persistent storage
 	// and returns the trie root key.
-	Commit() []byte
+	Commit() ([]byte, error)
 
 	// Proof returns the Merkle-proof associated with
-	// a node. An error is returned if the node is not found.
+	// a node as the RLP encodings of the nodes from the root.
+	// If the node is not found, the proof shows its absence.
 	Proof(key []byte) ([][]byte, error)
+
+	// Prove writes the Merkle-proof associated with a node
+	// as [hash, RLP encoding] pairs in the writer.
+	// If the node is not found, the proof shows its absence.
+	Prove(key []byte, w KeyValueWriter) error
+}
+
+// KeyValueWriter receives the nodes of a proof keyed by their hash.
+// It is compatible with go-ethereum's ethdb.KeyValueWriter.
+type KeyValueWriter interface {
+	Put(key []byte, value []byte) error
 }
 
 type Trie struct {
-	root    node.Node
-	db      store.DB
-	deleted map[string]struct{}
+	root      node.Node
+	db        store.DB
+	scheme    Scheme
+	owner     []byte
+	history   uint64
+	readOnly  bool
+	hashCheck bool
+	deleted   map[string]struct{}
+
+	cache       *nodeCache
+	cacheHits   atomic.Uint64
+	cacheMisses atomic.Uint64
 }
 
 func (t *Trie) Get(key []byte) ([]byte, error) {
@@ -62,115 +94,248 @@ func (t *Trie) Get(key []byte) ([]byte, error) {
 }
 
 func (t *Trie) Put(key []byte, value []byte) {
+	if err := t.Update(key, value); err != nil {
+		panic(err)
+	}
+}
+
+func (t *Trie) Update(key []byte, value []byte) error {
+	if t.readOnly {
+		return ErrReadOnly
+	}
+
 	path := encoding.ToHex(key)
-	t.root = t.put(t.root, path, 0, node.Leaf(value))
+
+	n, err := t.put(t.root, path, 0, node.Leaf(value))
+	if err != nil {
+		return err
+	}
+
+	t.root = n
+
+	return nil
 }
 
 func (t *Trie) Del(key []byte) error {
+	if t.readOnly {
+		return ErrReadOnly
+	}
+
+	// The nodes to delete are only marked once the whole deletion succeeds,
+	// otherwise the next commit would delete nodes which are still referenced.
+	deleted := make(map[string]struct{})
+
 	path := encoding.ToHex(key)
-	n, err := t.delete(t.root, nil, path)
+	n, err := t.delete(t.root, nil, path, deleted)
 	if err != nil {
 		return err
 	}
+
+	for prefix := range deleted {
+		t.deleted[prefix] = struct{}{}
+	}
+
 	t.root = n
+
 	return nil
 }
 
-func (t *Trie) Commit() []byte {
+func (t *Trie) Hash() []byte {
 	if t.root == nil {
 		return emptyRoot
 	}
 
-	if t.db == nil {
-		panic("db is not set")
-	}
-
-	for key := range t.deleted {
-		if err := t.db.Delete([]byte(key)); err != nil {
-			panic(err)
-		}
+	if hashed, ok := t.root.Hash().(node.Hashed); ok {
+		return hashed
 	}
 
-	hashedRoot, err := t.commit(nil, t.root)
+	// The root is always hashed, even if its encoding is < 32 bytes.
+	// Encoding cannot fail: the trie only holds branches, extensions, leaves and hashes,
+	// which are encoded in memory. The call to Hash above relies on the same invariant.
+	rlpEnc, err := node.Encode(t.root)
 	if err != nil {
 		panic(err)
-	} else if hashed, ok := hashedRoot.(node.Hashed); !ok {
-		panic(fmt.Sprintf("expected Hashed node, got: %T", hashedRoot))
-	} else {
-		t.root = hashed
-
-		return hashed
 	}
+
+	return crypto.Keccak256(rlpEnc)
 }
 
-func (t *Trie) commit(path []byte, n node.Node) (node.Node, error) {
-	var err error
+func (t *Trie) Commit() ([]byte, error) {
+	if t.readOnly {
+		return nil, ErrReadOnly
+	}
 
-	switch current := n.(type) {
-	case *node.Branch:
-		var ok bool
+	if t.db == nil {
+		if t.root == nil {
+			return emptyRoot, nil
+		}
 
-		hash := current.Hash()
+		return nil, ErrNoDB
+	}
 
-		for i := 0; i < node.BranchChildren; i++ {
-			if current.Children[i] == nil {
-				continue
-			}
+	if t.history > 0 && t.scheme != PathScheme {
+		return nil, fmt.Errorf("%w: history requires the %s scheme", ErrScheme, PathScheme)
+	}
 
-			if _, ok = current.Children[i].(node.Hashed); ok {
-				continue
-			}
+	var writes storeWrites
 
-			current.Children[i], err = t.commit(append(path, byte(i)), current.Children[i])
-			if err != nil {
+	if t.scheme == PathScheme { // With the hash scheme, the nodes are still referenced by older roots.
+		for key := range t.deleted {
+			writes = append(writes, storeWrite{key: t.nodeKey([]byte(key), nil)})
+		}
+	}
+
+	root := node.Hashed(emptyRoot)
+	if t.root != nil {
+		committed, err := t.commit(nil, t.root, &writes)
+		if err != nil {
+			return nil, err
+		}
+
+		var ok bool
+		if root, ok = committed.(node.Hashed); !ok { // The root is always stored, even if its encoding is < 32 bytes.
+			if root, err = t.store(nil, committed, &writes); err != nil {
 				return nil, err
 			}
 		}
+	}
 
-		var rlpEnc []byte
-		if rlpEnc, err = rlp.EncodeToBytes(current); err != nil {
+	if t.history > 0 {
+		var err error
+		if writes, err = t.recordHistory(root, writes); err != nil {
 			return nil, err
 		}
+	}
+
+	if err := writes.apply(t.db); err != nil {
+		return nil, err
+	}
+
+	t.root = nil
+	if !bytes.Equal(root, emptyRoot) {
+		t.root = root
+	}
+
+	t.deleted = make(map[string]struct{})
+
+	return root, nil
+}
 
-		if _, ok = hash.(node.Hashed); ok {
-			return hash, t.db.Put(path, rlpEnc)
+// storeWrite is a change to the store, which deletes the key if the value is nil.
+type storeWrite struct {
+	key   []byte
+	value []byte
+}
+
+// storeWrites are the changes to the store made by a commit, applied in order once all the nodes are encoded.
+type storeWrites []storeWrite
+
+// apply writes the changes in a single batch if the store supports it, so that either all or none are written.
+func (w storeWrites) apply(db store.DB) error {
+	var (
+		writer store.Writer = db
+		batch  store.Batch
+	)
+
+	if batcher, ok := db.(store.Batcher); ok {
+		batch = batcher.NewBatch()
+		writer = batch
+	}
+
+	for _, write := range w {
+		var err error
+		if write.value == nil {
+			err = writer.Delete(write.key)
+		} else {
+			err = writer.Put(write.key, write.value)
 		}
 
-		return hash, nil
+		if err != nil {
+			return err
+		}
+	}
 
-	case *node.Extension:
-		hash := current.Hash()
+	if batch != nil {
+		return batch.Write()
+	}
 
-		if next, ok := current.Next.(*node.Branch); ok {
-			if current.Next, err = t.commit(append(path, current.Key...), next); err != nil {
-				return nil, err
+	return nil
+}
+
+// commit stores the node and its descendants which are not hashed yet.
+// It returns the hash of the node, or the node itself if its encoding is < 32 bytes,
+// in which case it is embedded in its parent instead of being stored.
+// The node is not modified: its stored children are only replaced by their hash in a copy,
+// so that the trie is left untouched if the writes fail.
+func (t *Trie) commit(path []byte, n node.Node, writes *storeWrites) (node.Node, error) {
+	var err error
+
+	switch current := n.(type) {
+	case *node.Branch:
+		collapsed := current.Copy()
+		for i := 0; i < node.BranchChildren; i++ {
+			switch current.Children[i].(type) {
+			case *node.Branch, *node.Extension:
+				collapsed.Children[i], err = t.commit(append(path, byte(i)), current.Children[i], writes)
+				if err != nil {
+					return nil, err
+				}
 			}
 		}
 
-		// The key must be compacted first for RLP encoding.
-		current.Key = encoding.Compact(current.Key)
+		n = collapsed
 
-		var rlpEnc []byte
-		if rlpEnc, err = rlp.EncodeToBytes(current); err != nil {
-			return nil, err
-		}
+	case *node.Extension:
+		if next, ok := current.Next.(*node.Branch); ok {
+			collapsed := current.Copy()
+			if collapsed.Next, err = t.commit(append(path, current.Key...), next, writes); err != nil {
+				return nil, err
+			}
 
-		if _, ok := hash.(node.Hashed); ok {
-			return hash, t.db.Put(path, rlpEnc)
+			n = collapsed
 		}
 
-		return hash, nil
-
 	case node.Hashed:
 		return current, nil
 
 	case node.Leaf:
-		return nil, fmt.Errorf("leaf should not be stored directly")
+		return nil, fmt.Errorf("%w: leaf should not be stored directly", ErrNodeType)
 
 	default:
 		return nil, fmt.Errorf("%w: %T unknown", ErrNodeType, current)
 	}
 
+	if _, ok := n.Hash().(node.Hashed); !ok {
+		// The node is embedded in its parent. With the path scheme, a node stored earlier at its path
+		// (e.g. before a deletion shrank it) is stale. The root is always stored, so its path is kept.
+		if t.scheme == PathScheme && len(path) > 0 {
+			*writes = append(*writes, storeWrite{key: append([]byte(nil), t.nodeKey(path, nil)...)})
+		}
+
+		return n, nil
+	}
+
+	return t.store(path, n, writes)
+}
+
+// store adds the RLP encoding of the node at the given path, keyed as per the scheme, to the writes
+// and returns its hash.
+func (t *Trie) store(path []byte, n node.Node, writes *storeWrites) (node.Hashed, error) {
+	rlpEnc, err := node.Encode(n)
+	if err != nil {
+		return nil, err
+	}
+
+	hashed, ok := n.Hash().(node.Hashed)
+	if !ok { // The root is hashed even if its encoding is < 32 bytes.
+		hashed = crypto.Keccak256(rlpEnc)
+	}
+
+	// The path is copied since its backing array is reused for the following nodes.
+	key := append([]byte(nil), t.nodeKey(path, hashed)...)
+	*writes = append(*writes, storeWrite{key: key, value: rlpEnc})
+
+	return hashed, nil
 }
 
 func (t *Trie) Proof(key []byte) ([][]byte, error) {
@@ -180,11 +345,9 @@ func (t *Trie) Proof(key []byte) ([][]byte, error) {
 	depth := 0
 
 	// Get all the nodes from the root to the node at the given key.
-	for depth < len(path) {
+	// If the key is missing, stop at the node where the path diverges to prove its absence.
+	for depth < len(path) && nextNode != nil {
 		switch current := nextNode.(type) {
-		case nil:
-			return nil, ErrNotFound
-
 		case *node.Branch:
 			nextNode = current.Children[path[depth]]
 			depth += 1
@@ -194,13 +357,14 @@ func (t *Trie) Proof(key []byte) ([][]byte, error) {
 			keyLen := len(current.Key)
 
 			if len(path)-depth < keyLen || !bytes.Equal(path[depth:depth+keyLen], current.Key) {
-				return nil, ErrNotFound
+				nextNode = nil // The path diverges from the extension's key.
 			} else {
 				nextNode = current.Next
 				depth += keyLen
-				nodes = append(nodes, current)
 			}
 
+			nodes = append(nodes, current)
+
 		case node.Hashed:
 			if actual, err := t.loadHashed(path[:depth], current); err != nil {
 				return nil, err
@@ -213,43 +377,41 @@ func (t *Trie) Proof(key []byte) ([][]byte, error) {
 		}
 	}
 
-	if len(nodes) == 0 {
-		return nil, ErrNotFound
-	}
-
 	// Generate the proof.
-	var (
-		candidate node.Node
-		hashed    node.Hashed
-		ok        bool
-		err       error
-		rlpEnc    []byte
-	)
-
 	proof := make([][]byte, 0, len(nodes)) // Nodes len is a safe upper bound.
 	for i, n := range nodes {
-		candidate = n.Hash()
-
 		// Node.Hash() can return the node itself if its encoding is < 32 bytes.
 		// In this case, the node is included within its parent and should not
 		// be included in the proof directly.
 		// If this is the root (i == 0), then it must be included regardless.
-		if hashed, ok = candidate.(node.Hashed); ok || i == 0 {
-			if !ok {
-				if rlpEnc, err = rlp.EncodeToBytes(n); err != nil {
-					return nil, err
-				}
-
-				hashed = crypto.Keccak256(rlpEnc)
+		if _, ok := n.Hash().(node.Hashed); ok || i == 0 {
+			rlpEnc, err := node.Encode(n)
+			if err != nil {
+				return nil, err
 			}
 
-			proof = append(proof, hashed)
+			proof = append(proof, rlpEnc)
 		}
 	}
 
 	return proof, nil
 }
 
+func (t *Trie) Prove(key []byte, w KeyValueWriter) error {
+	proof, err := t.Proof(key)
+	if err != nil {
+		return err
+	}
+
+	for _, rlpEnc := range proof {
+		if err = w.Put(crypto.Keccak256(rlpEnc), rlpEnc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (t *Trie) get(curr node.Node, path []byte, depth int) ([]byte, error) {
 	switch current := curr.(type) {
 	case nil:
@@ -279,92 +441,130 @@ func (t *Trie) get(curr node.Node, path []byte, depth int) ([]byte, error) {
 		return t.get(actual, path, depth)
 
 	default:
-		return nil, fmt.Errorf("unknown node type: %T", current)
+		return nil, fmt.Errorf("%w: %T unknown", ErrNodeType, current)
 	}
 }
 
 func (t *Trie) loadHashed(path []byte, hashed node.Hashed) (node.Node, error) {
-	raw, err := t.db.Get(path)
+	if n, ok := t.cached(hashed); ok {
+		return n, nil
+	}
+
+	if t.db == nil {
+		return nil, &MissingNodeError{Path: path, Hash: hashed, Err: ErrNoDB}
+	}
+
+	raw, err := t.db.Get(t.nodeKey(path, hashed))
 	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return nil, &MissingNodeError{Path: path, Hash: hashed, Err: err}
 	case err != nil:
 		return nil, err
 	case raw == nil:
-		return nil, ErrNotFound
+		return nil, &MissingNodeError{Path: path, Hash: hashed, Err: store.ErrNotFound}
+	}
+
+	if t.hashCheck {
+		if actual := crypto.Keccak256(raw); !bytes.Equal(actual, hashed) {
+			return nil, &CorruptNodeError{Path: path, Expected: hashed, Actual: actual}
+		}
 	}
 
 	n, err := node.Decode(raw, hashed)
 	if err != nil {
-		return nil, fmt.Errorf("db: decode error: %v", err)
+		return nil, &DecodeError{Path: path, Hash: hashed, Err: err}
+	}
+
+	if t.cache != nil {
+		t.cache.Add(string(hashed), n)
 	}
 
 	return n, nil
 }
 
-func (t *Trie) put(curr node.Node, path []byte, depth int, value node.Node) node.Node {
+func (t *Trie) put(curr node.Node, path []byte, depth int, value node.Node) (node.Node, error) {
 	if len(path[depth:]) == 0 { // Trivial we just return the node
-		return value
+		return value, nil
 	}
 
 	switch current := curr.(type) {
 	case nil:
-		return node.NewExtension(path[depth:], value, nil)
+		return node.NewExtension(path[depth:], value, nil), nil
 
 	case *node.Branch:
 		branchKey := path[depth]
 
+		child, err := t.put(current.Children[branchKey], path, depth+1, value)
+		if err != nil {
+			return nil, err
+		}
+
 		current = current.Copy()
-		current.Children[branchKey] = t.put(current.Children[branchKey], path, depth+1, value)
+		current.Cache = nil
+		current.Children[branchKey] = child
 
-		return current
+		return current, nil
 
-	case *node.Leaf:
-		panic("Leaf should be put with parent Extension")
+	case node.Leaf:
+		return nil, fmt.Errorf("%w: leaf should be put with parent extension", ErrNodeType)
 
 	case *node.Extension:
 		match := encoding.CommonPrefixLen(path[depth:], current.Key)
 		if match == len(current.Key) { // Path longer than ext, travel down to next node.
-			next := t.put(current.Next, path, depth+match, value)
+			next, err := t.put(current.Next, path, depth+match, value)
+			if err != nil {
+				return nil, err
+			}
 
-			return node.NewExtension(current.Key, next, nil)
+			return node.NewExtension(current.Key, next, nil), nil
 		}
 
 		// Insert branch after matched prefix.
 		branch := node.NewBranch(nil)
 
+		var err error
+
 		// Insert extension's next as new child.
-		branch.Children[current.Key[match]] = t.put(nil, current.Key, match+1, current.Next)
+		branch.Children[current.Key[match]], err = t.put(nil, current.Key, match+1, current.Next)
+		if err != nil {
+			return nil, err
+		}
 
 		// Insert value as new child.
-		branch.Children[path[depth+match]] = t.put(nil, path, depth+match+1, value)
+		branch.Children[path[depth+match]], err = t.put(nil, path, depth+match+1, value)
+		if err != nil {
+			return nil, err
+		}
 
 		if match == 0 { // No path before the branch, so no need for an extension.
-			return branch
+			return branch, nil
 		}
 
 		// Create extension pointing to the branch:
-		return node.NewExtension(path[depth:depth+match], branch, nil)
+		return node.NewExtension(path[depth:depth+match], branch, nil), nil
 
 	case node.Hashed:
 		// The node is not loaded. Load it and continue the insertion from the actual node.
 		actual, err := t.loadHashed(path[:depth], current)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
 
 		return t.put(actual, path, depth, value)
 
 	default:
-		panic(fmt.Sprintf("invalid node type: %T", current))
+		return nil, fmt.Errorf("%w: %T unknown", ErrNodeType, current)
 	}
 }
 
-func (t *Trie) delete(n node.Node, prefix, key []byte) (node.Node, error) {
+// delete removes the key from the node and adds the paths of the stored nodes it removes to deleted.
+func (t *Trie) delete(n node.Node, prefix, key []byte, deleted map[string]struct{}) (node.Node, error) {
 	switch current := n.(type) {
 	case nil:
 		return nil, nil
 
 	case *node.Branch:
-		child, err := t.delete(current.Children[key[0]], append(prefix, key[0]), key[1:])
+		child, err := t.delete(current.Children[key[0]], append(prefix, key[0]), key[1:], deleted)
 		if err != nil {
 			return current, err
 		}
@@ -398,7 +598,7 @@ func (t *Trie) delete(n node.Node, prefix, key []byte) (node.Node, error) {
 
 		if lastBranch == node.BranchValue { // The last child is the value at the branch.
 			// Replace the branch with a new extension and the value.
-			t.deleted[string(append(prefix, node.BranchValue))] = struct{}{}
+			deleted[string(append(prefix, node.BranchValue))] = struct{}{}
 			return node.NewExtension(
 				[]byte{node.BranchValue}, current.Children[lastBranch], nil), nil
 		}
@@ -412,13 +612,18 @@ func (t *Trie) delete(n node.Node, prefix, key []byte) (node.Node, error) {
 			}
 		}
 
-		if extension, ok := newChild.(*node.Extension); ok {
-			extKey := append(make([]byte, 0, 1+len(extension.Key)), byte(lastBranch))
+		switch child := newChild.(type) {
+		case *node.Extension: // Merge the child extension in a new extension.
+			deleted[string(append(prefix, byte(lastBranch)))] = struct{}{}
+			extKey := append(make([]byte, 0, 1+len(child.Key)), byte(lastBranch))
+
+			return node.NewExtension(append(extKey, child.Key...), child.Next, nil), nil
 
-			return node.NewExtension(append(extKey, extension.Key...), extension.Next, nil), nil
+		case *node.Branch: // Point to the child branch (as is) with a new extension.
+			return node.NewExtension([]byte{byte(lastBranch)}, current.Children[lastBranch], nil), nil
 		}
 
-		return nil, fmt.Errorf("%w: %T unexpected", ErrNodeType, current)
+		return nil, fmt.Errorf("%w: %T unexpected", ErrNodeType, newChild)
 
 	case node.Leaf:
 		return nil, nil
@@ -430,8 +635,8 @@ func (t *Trie) delete(n node.Node, prefix, key []byte) (node.Node, error) {
 			return current, ErrNotFound
 
 		case match == len(key): // Matches the extension (with a leaf).
-			t.deleted[string(prefix)] = struct{}{} // Mark the node for deletion from the DB.
-			return nil, nil                        // Remove the extension.
+			deleted[string(prefix)] = struct{}{} // Mark the node for deletion from the DB.
+			return nil, nil                      // Remove the extension.
 		}
 
 		// Key matches more than the current extension, move down to the next node.
@@ -439,6 +644,7 @@ func (t *Trie) delete(n node.Node, prefix, key []byte) (node.Node, error) {
 			current.Next,
 			append(prefix, key[:len(current.Key)]...),
 			key[len(current.Key):],
+			deleted,
 		)
 
 		if err != nil {
@@ -448,13 +654,13 @@ func (t *Trie) delete(n node.Node, prefix, key []byte) (node.Node, error) {
 		// If the next node is also an extension, merge it in the current one.
 		if childExt, ok := nxt.(*node.Extension); ok {
 			// Mark the node for deletion from the DB.
-			t.deleted[string(append(prefix, current.Key...))] = struct{}{}
+			deleted[string(append(prefix, current.Key...))] = struct{}{}
 
-			return node.NewExtension( // Copy key to avoid memory sharing issues.
-				append(current.Key[:], childExt.Key[:]...),
-				childExt.Next,
-				nil,
-			), nil
+			// The key is allocated anew: the current key may share its backing array
+			// with a cached node, which is also used at other paths.
+			extKey := append(make([]byte, 0, len(current.Key)+len(childExt.Key)), current.Key...)
+
+			return node.NewExtension(append(extKey, childExt.Key...), childExt.Next, nil), nil
 		}
 
 		return node.NewExtension(current.Key, nxt, nil), nil
@@ -466,7 +672,7 @@ func (t *Trie) delete(n node.Node, prefix, key []byte) (node.Node, error) {
 			return nil, err
 		}
 
-		newNode, err := t.delete(actual, prefix, key)
+		newNode, err := t.delete(actual, prefix, key, deleted)
 		if err != nil {
 			return actual, err
 		}
@@ -478,10 +684,20 @@ func (t *Trie) delete(n node.Node, prefix, key []byte) (node.Node, error) {
 	}
 }
 
-func NewEmptyTrie(db store.DB) *Trie {
-	return &Trie{root: nil, db: db, deleted: make(map[string]struct{})}
+func NewEmptyTrie(db store.DB, opts ...Option) *Trie {
+	t := &Trie{root: nil, db: db, deleted: make(map[string]struct{})}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
 }
 
-func LoadTrie(db store.DB, root node.Hashed) *Trie {
-	return &Trie{root: root, db: db, deleted: make(map[string]struct{})}
+func LoadTrie(db store.DB, root node.Hashed, opts ...Option) *Trie {
+	t := NewEmptyTrie(db, opts...)
+	if !bytes.Equal(root, emptyRoot) {
+		t.root = root
+	}
+
+	return t
 }
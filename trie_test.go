@@ -4,14 +4,21 @@ package tfmpt
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"math/rand"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/trie"
 
+	"go.0xjac.com/tfmpt/crypto"
+	"go.0xjac.com/tfmpt/encoding"
 	"go.0xjac.com/tfmpt/store"
 )
 
@@ -100,7 +107,11 @@ func TestTrieDeleteThenCommit(t *testing.T) {
 				t.Errorf("Expected key=%s to be deleted, got err=%s", test.key, err)
 			}
 
-			root := mpt.Commit()
+			root, err := mpt.Commit()
+			if err != nil {
+				t.Fatalf("Expected trie to be committed, got err=%s", err)
+			}
+
 			mpt = LoadTrie(db, root)
 
 			for _, node := range nodes {
@@ -149,8 +160,8 @@ func TestTrieProof(t *testing.T) {
 				}
 
 				for _, part := range actual {
-					if _, ok := expected[string(part)]; !ok {
-						t.Errorf("Bad proof part=%064x", part)
+					if rlpEnc, ok := expected[string(crypto.Keccak256(part))]; !ok || !bytes.Equal(part, rlpEnc) {
+						t.Errorf("Bad proof part=%x", part)
 					}
 				}
 
@@ -164,15 +175,31 @@ func TestTrieProof(t *testing.T) {
 				func(t *testing.T) {
 					t.Parallel()
 
+					ethMPT := ethTrieFixture(t)
+
 					mpt, cleanup := trieSetup(t, commit)
 
+					actual := newMockEthProofDB(0)
+					if err := mpt.Prove(test, actual); err != nil {
+						t.Errorf("Expected an absence proof for key=%s, got err=%s", test, err)
+					}
+
+					expected := newMockEthProofDB(len(actual))
+					if err := ethMPT.Prove(test, expected); err != nil {
+						t.Errorf("Expected an absence proof for key=%s, got err=%s", test, err)
+					}
+
+					if !reflect.DeepEqual(actual, expected) {
+						t.Errorf("Expected proof=%x, got proof=%x", expected, actual)
+					}
+
 					proof, err := mpt.Proof(test)
-					if !errors.Is(err, ErrNotFound) {
-						t.Errorf("Expected key=%s to be missing, got err=%s", test, err)
+					if err != nil {
+						t.Errorf("Expected an absence proof for key=%s, got err=%s", test, err)
 					}
 
-					if proof != nil {
-						t.Errorf("Expected proof for key=%s to be nil, got %064x", test, proof)
+					if err = VerifyAbsence(mpt.Hash(), test, proof); err != nil {
+						t.Errorf("Expected key=%s to be proven absent, got err=%s", test, err)
 					}
 
 					cleanup()
@@ -181,6 +208,646 @@ func TestTrieProof(t *testing.T) {
 	}
 }
 
+func TestTrieProve(t *testing.T) {
+	for _, commit := range []bool{false, true} {
+		for _, test := range nodes {
+			t.Run(fmt.Sprintf("Prove[k=%s]%s", test.key, suffix(t, commit)), func(t *testing.T) {
+				t.Parallel()
+				ethMPT := ethTrieFixture(t)
+
+				mpt, cleanup := trieSetup(t, commit)
+
+				actual := newMockEthProofDB(0)
+				if err := mpt.Prove(test.key, actual); err != nil {
+					t.Errorf("Expected a proof for valid key=%s, got err=%s", test.key, err)
+				}
+
+				expected := newMockEthProofDB(len(actual))
+				if err := ethMPT.Prove(test.key, expected); err != nil {
+					t.Errorf("Expected a proof for valid key=%s, got err=%s", test.key, err)
+				}
+
+				if !reflect.DeepEqual(actual, expected) {
+					t.Errorf("Expected proof=%x, got proof=%x", expected, actual)
+				}
+
+				proof, err := mpt.Proof(test.key)
+				if err != nil {
+					t.Errorf("Expected a proof for valid key=%s, got err=%s", test.key, err)
+				}
+
+				val, err := VerifyProof(mpt.Hash(), test.key, proof)
+				assertPresent(t, test.key, val, test.val, err)
+
+				cleanup()
+			})
+		}
+	}
+
+	t.Run("Prove[small_root]", func(t *testing.T) {
+		t.Parallel()
+
+		mpt := NewEmptyTrie(nil)
+		mpt.Put([]byte("a"), []byte("b"))
+
+		ethMPT := trie.NewEmpty(nil)
+		ethMPT.MustUpdate([]byte("a"), []byte("b"))
+
+		actual, expected := newMockEthProofDB(0), newMockEthProofDB(0)
+		if err := mpt.Prove([]byte("a"), actual); err != nil {
+			t.Errorf("Expected a proof for valid key=a, got err=%s", err)
+		}
+
+		if err := ethMPT.Prove([]byte("a"), expected); err != nil {
+			t.Errorf("Expected a proof for valid key=a, got err=%s", err)
+		}
+
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Expected proof=%x, got proof=%x", expected, actual)
+		}
+	})
+}
+
+func TestTrieHash(t *testing.T) {
+	newVal := []byte("<new_val>")
+
+	for _, commit := range []bool{false, true} {
+		for _, test := range nodes {
+			t.Run(fmt.Sprintf("Hash[k=%s]%s", test.key, suffix(t, commit)), func(t *testing.T) {
+				t.Parallel()
+				ethMPT := ethTrieFixture(t)
+
+				mpt, cleanup := trieSetup(t, commit)
+
+				if actual, expected := mpt.Hash(), ethMPT.Hash(); !bytes.Equal(actual, expected[:]) {
+					t.Errorf("Expected root=%064x, got root=%064x", expected, actual)
+				}
+
+				mpt.Put(test.key, newVal)
+				ethMPT.MustUpdate(test.key, newVal)
+
+				if actual, expected := mpt.Hash(), ethMPT.Hash(); !bytes.Equal(actual, expected[:]) {
+					t.Errorf("Expected root=%064x, got root=%064x", expected, actual)
+				}
+
+				cleanup()
+			})
+		}
+	}
+
+	t.Run("Hash[empty]", func(t *testing.T) {
+		t.Parallel()
+
+		if actual := NewEmptyTrie(nil).Hash(); !bytes.Equal(actual, emptyRoot) {
+			t.Errorf("Expected root=%064x, got root=%064x", emptyRoot, actual)
+		}
+	})
+
+	t.Run("Hash[small_root]", func(t *testing.T) {
+		t.Parallel()
+
+		mpt := NewEmptyTrie(nil)
+		mpt.Put([]byte("a"), []byte("b"))
+
+		ethMPT := trie.NewEmpty(nil)
+		ethMPT.MustUpdate([]byte("a"), []byte("b"))
+
+		if actual, expected := mpt.Hash(), ethMPT.Hash(); !bytes.Equal(actual, expected[:]) {
+			t.Errorf("Expected root=%064x, got root=%064x", expected, actual)
+		}
+	})
+}
+
+func TestTrieHashThenCommit(t *testing.T) {
+	db, cleanup := storageFixture(t)
+	defer cleanup()
+
+	mpt := trieFixture(t, db)
+
+	hash := mpt.Hash()
+	if _, err := db.Get(nil); err == nil {
+		t.Errorf("Expected nothing to be stored before commit")
+	}
+
+	if root, err := mpt.Commit(); err != nil {
+		t.Errorf("Expected trie to be committed, got err=%s", err)
+	} else if !bytes.Equal(hash, root) {
+		t.Errorf("Expected committed root=%064x, got root=%064x", hash, root)
+	}
+}
+
+func TestTrieDeleteCollapse(t *testing.T) {
+	for _, commit := range []bool{false, true} {
+		t.Run(fmt.Sprintf("Del[collapse]%s", suffix(t, commit)), func(t *testing.T) {
+			t.Parallel()
+
+			db, cleanup := storageFixture(t)
+			defer cleanup()
+
+			mpt := NewEmptyTrie(db)
+			ethMPT := trie.NewEmpty(nil)
+
+			// Deleting "a" leaves its branch with a single child, which is also a branch.
+			for _, key := range []string{"a", "ab", "ac"} {
+				mpt.Put([]byte(key), []byte(key))
+				ethMPT.MustUpdate([]byte(key), []byte(key))
+			}
+
+			if commit {
+				root, err := mpt.Commit()
+				if err != nil {
+					t.Fatalf("Expected trie to be committed, got err=%s", err)
+				}
+
+				mpt = LoadTrie(db, root)
+			}
+
+			if err := mpt.Del([]byte("a")); err != nil {
+				t.Fatalf("Expected key=a to be deleted, got err=%s", err)
+			}
+
+			ethMPT.MustDelete([]byte("a"))
+
+			if actual, expected := mpt.Hash(), ethMPT.Hash(); !bytes.Equal(actual, expected[:]) {
+				t.Errorf("Expected root=%064x, got root=%064x", expected, actual)
+			}
+
+			for _, key := range []string{"ab", "ac"} {
+				val, err := mpt.Get([]byte(key))
+				assertPresent(t, []byte(key), val, []byte(key), err)
+			}
+		})
+	}
+}
+
+func TestTrieDeleteMergeExtension(t *testing.T) {
+	db, cleanup := storageFixture(t)
+	defer cleanup()
+
+	// The root branch has a leaf under 0x1 and an extension under 0x2, both stored on their own.
+	value := bytes.Repeat([]byte{0xff}, 40)
+	mpt := NewEmptyTrie(db)
+	for _, key := range [][]byte{{0x10}, {0x20, 0x00}, {0x20, 0x01}} {
+		mpt.Put(key, value)
+	}
+
+	if _, err := mpt.Commit(); err != nil {
+		t.Fatalf("Expected trie to be committed, got err=%s", err)
+	}
+
+	if _, err := db.Get([]byte{0x02}); err != nil {
+		t.Fatalf("Expected the extension to be stored, got err=%s", err)
+	}
+
+	// Deleting 0x10 merges the extension under 0x2 in a new root extension.
+	if err := mpt.Del([]byte{0x10}); err != nil {
+		t.Fatalf("Expected key=%x to be deleted, got err=%s", []byte{0x10}, err)
+	}
+
+	root, err := mpt.Commit()
+	if err != nil {
+		t.Fatalf("Expected trie to be committed, got err=%s", err)
+	}
+
+	if _, err = db.Get([]byte{0x02}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Expected the merged extension to be deleted, got err=%v", err)
+	}
+
+	mpt = LoadTrie(db, root)
+	for _, key := range [][]byte{{0x20, 0x00}, {0x20, 0x01}} {
+		val, err := mpt.Get(key)
+		assertPresent(t, key, val, value, err)
+	}
+}
+
+func TestTrieDeleteAllThenCommit(t *testing.T) {
+	db, cleanup := storageFixture(t)
+	defer cleanup()
+
+	mpt := trieFixture(t, db)
+	if _, err := mpt.Commit(); err != nil {
+		t.Fatalf("Expected trie to be committed, got err=%s", err)
+	}
+
+	for _, node := range nodes {
+		if err := mpt.Del(node.key); err != nil {
+			t.Fatalf("Expected key=%s to be deleted, got err=%s", node.key, err)
+		}
+	}
+
+	root, err := mpt.Commit()
+	if err != nil {
+		t.Fatalf("Expected trie to be committed, got err=%s", err)
+	} else if !bytes.Equal(root, emptyRoot) {
+		t.Errorf("Expected root=%064x, got root=%064x", emptyRoot, root)
+	}
+
+	mpt = LoadTrie(db, root)
+	for _, node := range nodes {
+		val, err := mpt.Get(node.key)
+		assertMissing(t, node.key, val, err)
+	}
+}
+
+func TestTrieCommitErrors(t *testing.T) {
+	t.Run("Commit[no_db]", func(t *testing.T) {
+		t.Parallel()
+
+		mpt := trieFixture(t, nil)
+
+		if root, err := mpt.Commit(); !errors.Is(err, ErrNoDB) {
+			t.Errorf("Expected commit to fail with err=%s, got root=%064x, err=%s", ErrNoDB, root, err)
+		}
+	})
+
+	t.Run("Commit[small_root]", func(t *testing.T) {
+		t.Parallel()
+
+		db, cleanup := storageFixture(t)
+		defer cleanup()
+
+		mpt := NewEmptyTrie(db)
+		mpt.Put([]byte("a"), []byte("b"))
+
+		root, err := mpt.Commit()
+		if err != nil {
+			t.Fatalf("Expected trie to be committed, got err=%s", err)
+		}
+
+		val, err := LoadTrie(db, root).Get([]byte("a"))
+		assertPresent(t, []byte("a"), val, []byte("b"), err)
+	})
+
+	t.Run("Update[missing_node]", func(t *testing.T) {
+		t.Parallel()
+
+		db, cleanup := storageFixture(t)
+		defer cleanup()
+
+		root, err := trieFixture(t, db).Commit()
+		if err != nil {
+			t.Fatalf("Expected trie to be committed, got err=%s", err)
+		}
+
+		if err = db.Delete(nil); err != nil {
+			t.Fatal(err)
+		}
+
+		var missing *MissingNodeError
+		if err = LoadTrie(db, root).Update([]byte("dog"), []byte("<new_val>")); !errors.As(err, &missing) {
+			t.Errorf("Expected a missing node error, got err=%s", err)
+		} else if !bytes.Equal(missing.Hash, root) {
+			t.Errorf("Expected missing node=%064x, got node=%064x", root, missing.Hash)
+		}
+	})
+
+	t.Run("Update[decode_failure]", func(t *testing.T) {
+		t.Parallel()
+
+		db, cleanup := storageFixture(t)
+		defer cleanup()
+
+		root, err := trieFixture(t, db).Commit()
+		if err != nil {
+			t.Fatalf("Expected trie to be committed, got err=%s", err)
+		}
+
+		if err = db.Put(nil, []byte{0xc0}); err != nil {
+			t.Fatal(err)
+		}
+
+		var decode *DecodeError
+		if err = LoadTrie(db, root).Update([]byte("dog"), []byte("<new_val>")); !errors.As(err, &decode) {
+			t.Errorf("Expected a decode error, got err=%s", err)
+		}
+	})
+
+	t.Run("Commit[batch_failure]", func(t *testing.T) {
+		t.Parallel()
+
+		db, cleanup := storageFixture(t)
+		defer cleanup()
+
+		errWrite := errors.New("<write_failure>")
+		failing := &failingBatchDB{DB: db, err: errWrite}
+
+		mpt, _, pairs := randomTrieFixture(t, 100)
+		root := mpt.Hash()
+		mpt.db = failing
+
+		if _, err := mpt.Commit(); !errors.Is(err, errWrite) {
+			t.Fatalf("Expected commit to fail with err=%s, got err=%s", errWrite, err)
+		}
+
+		if failing.direct > 0 {
+			t.Errorf("Expected all the writes in a batch, got %d direct writes", failing.direct)
+		}
+
+		// None of the nodes were written.
+		var missing *MissingNodeError
+		if _, err := LoadTrie(db, root).Get(pairs[0].key); !errors.As(err, &missing) {
+			t.Errorf("Expected a missing node error, got err=%s", err)
+		}
+
+		// The trie is left untouched, so the commit can be retried.
+		mpt.db = db
+
+		committed, err := mpt.Commit()
+		if err != nil {
+			t.Fatalf("Expected trie to be committed, got err=%s", err)
+		} else if !bytes.Equal(committed, root) {
+			t.Fatalf("Expected root=%064x, got root=%064x", root, committed)
+		}
+
+		loaded := LoadTrie(db, committed)
+		for _, p := range pairs {
+			val, err := loaded.Get(p.key)
+			assertPresent(t, p.key, val, p.val, err)
+		}
+	})
+
+	t.Run("Del[load_failure]", func(t *testing.T) {
+		t.Parallel()
+
+		db, cleanup := storageFixture(t)
+		defer cleanup()
+
+		// Both leaves are stored, as their encoding is >= 32 bytes.
+		value := bytes.Repeat([]byte{0xff}, 40)
+		mpt := NewEmptyTrie(db)
+		mpt.Put([]byte{0x10}, value)
+		mpt.Put([]byte{0x20}, value)
+
+		root, err := mpt.Commit()
+		if err != nil {
+			t.Fatalf("Expected trie to be committed, got err=%s", err)
+		}
+
+		// Deleting 0x10 collapses the root, which loads the leaf of 0x20 after the one of 0x10 is removed.
+		errRead := errors.New("<read_failure>")
+		failing := &failingGetDB{DB: db, key: []byte{0x02}, err: errRead, failures: 1}
+
+		mpt = LoadTrie(failing, root)
+		if err = mpt.Del([]byte{0x10}); !errors.Is(err, errRead) {
+			t.Fatalf("Expected delete to fail with err=%s, got err=%s", errRead, err)
+		}
+
+		if committed, err := mpt.Commit(); err != nil {
+			t.Fatalf("Expected trie to be committed, got err=%s", err)
+		} else if !bytes.Equal(committed, root) {
+			t.Fatalf("Expected root=%064x, got root=%064x", root, committed)
+		}
+
+		mpt = LoadTrie(db, root)
+		for _, key := range [][]byte{{0x10}, {0x20}} {
+			val, err := mpt.Get(key)
+			assertPresent(t, key, val, value, err)
+		}
+	})
+}
+
+// failingGetDB is a store which fails to read a key a number of times.
+type failingGetDB struct {
+	store.DB
+	key      []byte
+	err      error
+	failures int
+}
+
+func (db *failingGetDB) Get(key []byte) ([]byte, error) {
+	if db.failures > 0 && bytes.Equal(key, db.key) {
+		db.failures -= 1
+		return nil, db.err
+	}
+
+	return db.DB.Get(key)
+}
+
+// failingBatchDB is a store whose batches fail to be written, and which counts the writes made outside a batch.
+type failingBatchDB struct {
+	store.DB
+	err    error
+	direct int
+}
+
+func (db *failingBatchDB) Put(key, value []byte) error {
+	db.direct += 1
+	return db.DB.Put(key, value)
+}
+
+func (db *failingBatchDB) Delete(key []byte) error {
+	db.direct += 1
+	return db.DB.Delete(key)
+}
+
+func (db *failingBatchDB) NewBatch() store.Batch {
+	return &failingBatch{Batch: db.DB.(store.Batcher).NewBatch(), err: db.err}
+}
+
+type failingBatch struct {
+	store.Batch
+	err error
+}
+
+func (b *failingBatch) Write() error { return b.err }
+
+func TestTrieHashScheme(t *testing.T) {
+	db, cleanup := storageFixture(t)
+	defer cleanup()
+
+	_, _, pairs := randomTrieFixture(t, 300)
+
+	mpt := NewEmptyTrie(db, WithScheme(HashScheme))
+	for _, p := range pairs {
+		mpt.Put(p.key, p.val)
+	}
+
+	oldRoot, err := mpt.Commit()
+	if err != nil {
+		t.Fatalf("Expected trie to be committed, got err=%s", err)
+	}
+
+	// Update a third of the keys and delete another third.
+	for i, p := range pairs {
+		switch i % 3 {
+		case 1:
+			mpt.Put(p.key, append([]byte("<new_val>"), p.val...))
+		case 2:
+			if err = mpt.Del(p.key); err != nil {
+				t.Fatalf("Expected key=%x to be deleted, got err=%s", p.key, err)
+			}
+		}
+	}
+
+	newRoot, err := mpt.Commit()
+	if err != nil {
+		t.Fatalf("Expected trie to be committed, got err=%s", err)
+	}
+
+	// Both versions must be readable.
+	oldMPT, newMPT := LoadTrie(db, oldRoot, WithScheme(HashScheme)), LoadTrie(db, newRoot, WithScheme(HashScheme))
+	for i, p := range pairs {
+		val, err := oldMPT.Get(p.key)
+		assertPresent(t, p.key, val, p.val, err)
+
+		val, err = newMPT.Get(p.key)
+		switch i % 3 {
+		case 0:
+			assertPresent(t, p.key, val, p.val, err)
+		case 1:
+			assertPresent(t, p.key, val, append([]byte("<new_val>"), p.val...), err)
+		case 2:
+			assertMissing(t, p.key, val, err)
+		}
+	}
+
+	// The nodes are keyed by hash, not by path.
+	var missing *MissingNodeError
+	if _, err = LoadTrie(db, oldRoot).Get(pairs[0].key); !errors.As(err, &missing) {
+		t.Errorf("Expected a missing node error with the path scheme, got err=%s", err)
+	}
+}
+
+func TestTrieOwners(t *testing.T) {
+	for _, scheme := range []Scheme{PathScheme, HashScheme} {
+		t.Run(fmt.Sprintf("Owners[%s]", scheme), func(t *testing.T) {
+			t.Parallel()
+
+			db, cleanup := storageFixture(t)
+			defer cleanup()
+
+			_, _, pairs := randomTrieFixture(t, 100)
+			owners := [][]byte{crypto.Keccak256([]byte("first")), crypto.Keccak256([]byte("second"))}
+			roots := make([][]byte, len(owners))
+
+			// Both tries hold the same keys with different values.
+			for i, owner := range owners {
+				mpt := NewEmptyTrie(db, WithScheme(scheme), WithOwner(owner))
+				for _, p := range pairs {
+					mpt.Put(p.key, append([]byte{byte(i)}, p.val...))
+				}
+
+				root, err := mpt.Commit()
+				if err != nil {
+					t.Fatalf("Expected trie to be committed, got err=%s", err)
+				}
+
+				roots[i] = root
+			}
+
+			// Deleting keys from the first trie must not delete the nodes of the second one.
+			first := LoadTrie(db, roots[0], WithScheme(scheme), WithOwner(owners[0]))
+			for _, p := range pairs[:len(pairs)/2] {
+				if err := first.Del(p.key); err != nil {
+					t.Fatalf("Expected key=%x to be deleted, got err=%s", p.key, err)
+				}
+			}
+
+			if _, err := first.Commit(); err != nil {
+				t.Fatalf("Expected trie to be committed, got err=%s", err)
+			}
+
+			second := LoadTrie(db, roots[1], WithScheme(scheme), WithOwner(owners[1]))
+			for _, p := range pairs {
+				val, err := second.Get(p.key)
+				assertPresent(t, p.key, val, append([]byte{1}, p.val...), err)
+			}
+		})
+	}
+}
+
+func TestTriePebble(t *testing.T) {
+	dbPath := t.TempDir()
+
+	db, err := store.NewPebble(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mpt, _, pairs := randomTrieFixture(t, 300)
+	mpt.db = db
+
+	if _, err = mpt.Commit(); err != nil {
+		t.Fatalf("Expected trie to be committed, got err=%s", err)
+	}
+
+	// Delete half the keys, so that nodes are also deleted from the store.
+	for _, p := range pairs[:150] {
+		if err = mpt.Del(p.key); err != nil {
+			t.Fatalf("Expected key=%x to be deleted, got err=%s", p.key, err)
+		}
+	}
+
+	root, err := mpt.Commit()
+	if err != nil {
+		t.Fatalf("Expected trie to be committed, got err=%s", err)
+	}
+
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = store.NewPebbleReadOnly(filepath.Join(dbPath, "<missing>")); err == nil {
+		t.Errorf("Expected a missing store not to be opened")
+	}
+
+	if db, err = store.NewPebbleReadOnly(dbPath); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if err = db.Put([]byte("<key>"), []byte("<val>")); err == nil {
+		t.Errorf("Expected write to the read-only store to fail")
+	}
+
+	mpt = LoadTrie(db, root)
+	for _, p := range pairs[:150] {
+		val, err := mpt.Get(p.key)
+		assertMissing(t, p.key, val, err)
+	}
+
+	for _, p := range pairs[150:] {
+		val, err := mpt.Get(p.key)
+		assertPresent(t, p.key, val, p.val, err)
+	}
+
+	if report, err := Verify(db, root); err != nil || !report.OK() {
+		t.Errorf("Expected a sound trie, got report=%+v err=%v", report, err)
+	}
+}
+
+func TestTrieHashCheck(t *testing.T) {
+	db, root, pairs := committedTrieFixture(t, 300)
+	paths := rootChildrenPaths(t, db)
+
+	// Swap two nodes, such that the nodes at both paths are valid but not the expected ones.
+	first, second := mustGet(t, db, paths[0]), mustGet(t, db, paths[1])
+	mustPut(t, db, paths[0], second)
+	mustPut(t, db, paths[1], first)
+
+	mpt := LoadTrie(db, root, WithHashCheck())
+
+	for _, p := range pairs {
+		if encoding.ToHex(p.key)[0] != paths[0][0] {
+			continue // Only the keys under the first swapped node are checked.
+		}
+
+		var corrupt *CorruptNodeError
+		if _, err := mpt.Get(p.key); !errors.As(err, &corrupt) {
+			t.Fatalf("Expected a corrupt node error for key=%x, got err=%s", p.key, err)
+		}
+
+		if !bytes.Equal(corrupt.Path, paths[0]) || !bytes.Equal(corrupt.Actual, crypto.Keccak256(second)) {
+			t.Errorf("Expected corrupt node at path=%x with hash=%x, got path=%x hash=%x",
+				paths[0], crypto.Keccak256(second), corrupt.Path, corrupt.Actual)
+		}
+
+		if !bytes.Equal(corrupt.Expected, crypto.Keccak256(first)) {
+			t.Errorf("Expected corrupt node to be hash=%x, got hash=%x", crypto.Keccak256(first), corrupt.Expected)
+		}
+	}
+}
+
 func assertPresent(t *testing.T, key, val, expected []byte, err error) {
 	t.Helper()
 
@@ -193,6 +860,47 @@ func assertPresent(t *testing.T, key, val, expected []byte, err error) {
 	}
 }
 
+func BenchmarkTrieCommit(b *testing.B) {
+	backends := map[string]func(dbPath string) (store.DB, error){
+		"leveldb": func(dbPath string) (store.DB, error) { return store.NewLevelDB(dbPath) },
+		"pebble":  func(dbPath string) (store.DB, error) { return store.NewPebble(dbPath) },
+		"memory":  func(string) (store.DB, error) { return store.NewMemoryDB(), nil },
+	}
+
+	r := rand.New(rand.NewSource(7))
+	keys, values := make([][]byte, 1000), make([][]byte, 1000)
+
+	for i := range keys {
+		keys[i], values[i] = make([]byte, 32), make([]byte, 64)
+		r.Read(keys[i])
+		r.Read(values[i])
+	}
+
+	for name, open := range backends {
+		b.Run(name, func(b *testing.B) {
+			db, err := open(b.TempDir())
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer db.Close()
+
+			mpt := NewEmptyTrie(db)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for j, key := range keys {
+					binary.BigEndian.PutUint64(values[j], uint64(i))
+					mpt.Put(key, values[j])
+				}
+
+				if _, err = mpt.Commit(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 func assertMissing(t *testing.T, key, val []byte, err error) {
 	t.Helper()
 
@@ -241,7 +949,11 @@ func trieSetup(t *testing.T, commit bool) (iTrie, func()) {
 	mpt := trieFixture(t, db)
 
 	if commit {
-		root := mpt.Commit()
+		root, err := mpt.Commit()
+		if err != nil {
+			t.Fatalf("Expected trie to be committed, got err=%s", err)
+		}
+
 		mpt = LoadTrie(db, root)
 	}
 
@@ -306,7 +1018,116 @@ func storageFixture(t *testing.T) (store.DB, func()) {
 	return db, cleanup
 }
 
-var _ ethdb.KeyValueWriter = (mockEthProofDB)(nil)
+type pair struct {
+	key []byte
+	val []byte
+}
+
+// sortedNodes returns the nodes of the fixture in key order.
+func sortedNodes() []pair {
+	sorted := make([]pair, 0, len(nodes))
+	for _, node := range nodes {
+		sorted = append(sorted, pair{key: node.key, val: node.val})
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i].key, sorted[j].key) < 0 })
+
+	return sorted
+}
+
+// randomTrieFixture returns a trie with n random [key, value] pairs, its root and the pairs in key order.
+func randomTrieFixture(t *testing.T, n int) (*Trie, []byte, []pair) {
+	t.Helper()
+
+	r := rand.New(rand.NewSource(int64(n)))
+	mpt := NewEmptyTrie(nil)
+	pairs := make(map[string]pair, n)
+
+	for len(pairs) < n {
+		key, val := make([]byte, 1+r.Intn(8)), make([]byte, 1+r.Intn(48))
+		r.Read(key)
+		r.Read(val)
+
+		mpt.Put(key, val)
+		pairs[string(key)] = pair{key: key, val: val}
+	}
+
+	sorted := make([]pair, 0, n)
+	for _, p := range pairs {
+		sorted = append(sorted, p)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i].key, sorted[j].key) < 0 })
+
+	return mpt, mpt.Hash(), sorted
+}
+
+// committedTrieFixture returns a store with a random trie of n pairs committed in it, its root and its pairs.
+func committedTrieFixture(t *testing.T, n int) (*store.MemoryDB, []byte, []pair) {
+	t.Helper()
+
+	_, _, pairs := randomTrieFixture(t, n)
+
+	db := store.NewMemoryDB()
+	mpt := NewEmptyTrie(db)
+
+	for _, p := range pairs {
+		mpt.Put(p.key, p.val)
+	}
+
+	root, err := mpt.Commit()
+	if err != nil {
+		t.Fatalf("Expected trie to be committed, got err=%s", err)
+	}
+
+	return db, root, pairs
+}
+
+// rootChildrenPaths returns the paths of the children of the root in the store, in order.
+func rootChildrenPaths(t *testing.T, db *store.MemoryDB) [][]byte {
+	t.Helper()
+
+	var paths [][]byte
+
+	it := db.NewIterator(nil, nil, nil)
+	defer it.Release()
+
+	for it.Next() {
+		if len(it.Key()) == 1 {
+			paths = append(paths, append([]byte{}, it.Key()...))
+		}
+	}
+
+	if len(paths) < 4 {
+		t.Fatalf("Expected at least 4 children, got %d children", len(paths))
+	}
+
+	return paths
+}
+
+func mustGet(t *testing.T, db store.DB, key []byte) []byte {
+	t.Helper()
+
+	value, err := db.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return value
+}
+
+func mustPut(t *testing.T, db store.DB, key, value []byte) {
+	t.Helper()
+
+	if err := db.Put(key, value); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var (
+	_ ethdb.KeyValueWriter = (mockEthProofDB)(nil)
+	_ KeyValueWriter       = (mockEthProofDB)(nil)
+)
 
 type mockEthProofDB map[string][]byte
 
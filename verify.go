@@ -0,0 +1,125 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"bytes"
+	"errors"
+
+	"go.0xjac.com/tfmpt/crypto"
+	"go.0xjac.com/tfmpt/encoding"
+	"go.0xjac.com/tfmpt/node"
+	"go.0xjac.com/tfmpt/store"
+)
+
+// Report is the result of the verification of a trie in a store.
+type Report struct {
+	Nodes   int                 // Nodes is the number of sound nodes reachable from the root.
+	Missing []*MissingNodeError // Missing are the nodes reachable from the root absent from the store.
+	Decode  []*DecodeError      // Decode are the nodes reachable from the root which cannot be decoded.
+	Corrupt []*CorruptNodeError // Corrupt are the nodes reachable from the root which do not match their hash.
+
+	// Orphans are the keys of the nodes in the store which are not reachable from the root.
+	// They are only listed if the store implements store.Iteratee.
+	Orphans [][]byte
+}
+
+// OK indicates whether every node reachable from the root is sound and no node is orphaned.
+func (r *Report) OK() bool {
+	return len(r.Missing) == 0 && len(r.Decode) == 0 && len(r.Corrupt) == 0 && len(r.Orphans) == 0
+}
+
+// Verify walks every node reachable from the root of a trie in the store, committed with the given options.
+// It checks each node is in the store, can be decoded and matches the hash referencing it in its parent.
+// The problems found are listed in the report, an error is only returned if the store cannot be read.
+//
+// With the HashScheme, the nodes of other roots in the store are reported as orphans.
+func Verify(db store.DB, root []byte, opts ...Option) (*Report, error) {
+	v := &verifier{trie: NewEmptyTrie(db, opts...), report: &Report{}, reachable: make(map[string]struct{})}
+
+	if !bytes.Equal(root, emptyRoot) {
+		if err := v.verify(nil, root); err != nil {
+			return nil, err
+		}
+	}
+
+	if iteratee, ok := db.(store.Iteratee); ok {
+		if err := v.orphans(iteratee); err != nil {
+			return nil, err
+		}
+	}
+
+	return v.report, nil
+}
+
+type verifier struct {
+	trie      *Trie
+	report    *Report
+	reachable map[string]struct{} // reachable are the keys in the store of the nodes visited.
+}
+
+func (v *verifier) verify(path []byte, hashed node.Hashed) error {
+	key := v.trie.nodeKey(path, hashed)
+	if _, ok := v.reachable[string(key)]; ok {
+		return nil // The node is shared, and was already verified.
+	}
+
+	v.reachable[string(key)] = struct{}{}
+
+	raw, err := v.trie.db.Get(key)
+	switch {
+	case errors.Is(err, store.ErrNotFound) || (err == nil && raw == nil):
+		v.report.Missing = append(v.report.Missing, &MissingNodeError{Path: path, Hash: hashed, Err: store.ErrNotFound})
+		return nil
+	case err != nil:
+		return err
+	}
+
+	n, err := node.Decode(raw, hashed)
+	if err != nil {
+		v.report.Decode = append(v.report.Decode, &DecodeError{Path: path, Hash: hashed, Err: err})
+		return nil
+	}
+
+	if actual := crypto.Keccak256(raw); !bytes.Equal(actual, hashed) {
+		v.report.Corrupt = append(v.report.Corrupt, &CorruptNodeError{Path: path, Expected: hashed, Actual: actual})
+		return nil // The children referenced by a corrupt node cannot be trusted.
+	}
+
+	v.report.Nodes += 1
+
+	return hashedChildren(n, path, v.verify)
+}
+
+// orphans lists the nodes of the trie in the store which were not visited.
+func (v *verifier) orphans(iteratee store.Iteratee) error {
+	it := iteratee.NewIterator(v.trie.owner, nil, nil)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if _, ok := v.reachable[string(key)]; ok || !v.isNodeKey(key[len(v.trie.owner):]) {
+			continue
+		}
+
+		v.report.Orphans = append(v.report.Orphans, append([]byte{}, key...))
+	}
+
+	return it.Error()
+}
+
+// isNodeKey indicates whether a key in the store, without the owner, is the key of a node as per the scheme.
+// This excludes the other keys such as the ones of the history and of the reference counts.
+func (v *verifier) isNodeKey(key []byte) bool {
+	if v.trie.scheme == HashScheme {
+		return len(key) == len(emptyRoot)
+	}
+
+	for _, nibble := range key {
+		if nibble > encoding.AlphabetSize {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,155 @@
+// Copyright (C) 2024 Jacques Dafflon | 0xjac - All Rights Reserved
+
+package tfmpt
+
+import (
+	"bytes"
+	"testing"
+
+	"go.0xjac.com/tfmpt/store"
+)
+
+func TestVerify(t *testing.T) {
+	t.Run("Verify[sound]", func(t *testing.T) {
+		t.Parallel()
+
+		db, root, _ := committedTrieFixture(t, 300)
+
+		report, err := Verify(db, root)
+		if err != nil {
+			t.Fatalf("Expected trie to be verified, got err=%s", err)
+		}
+
+		if !report.OK() || report.Nodes == 0 {
+			t.Errorf("Expected a sound trie, got report=%+v", report)
+		}
+	})
+
+	t.Run("Verify[problems]", func(t *testing.T) {
+		t.Parallel()
+
+		db, root, _ := committedTrieFixture(t, 300)
+		paths := rootChildrenPaths(t, db)
+
+		// Swap two nodes, remove one, garble one and add an orphan.
+		first, second := mustGet(t, db, paths[0]), mustGet(t, db, paths[1])
+		mustPut(t, db, paths[0], second)
+		mustPut(t, db, paths[1], first)
+		mustPut(t, db, paths[2], []byte{0xc0})
+
+		if err := db.Delete(paths[3]); err != nil {
+			t.Fatal(err)
+		}
+
+		orphan := []byte{0x0f, 0x0f, 0x0f, 0x0f, 0x0f, 0x0f, 0x0f, 0x0f, 0x0f, 0x0f}
+		mustPut(t, db, orphan, first)
+
+		report, err := Verify(db, root)
+		if err != nil {
+			t.Fatalf("Expected trie to be verified, got err=%s", err)
+		}
+
+		if report.OK() {
+			t.Fatalf("Expected problems, got report=%+v", report)
+		}
+
+		for i, corrupt := range report.Corrupt {
+			if !bytes.Equal(corrupt.Path, paths[i]) {
+				t.Errorf("Expected corrupt node at path=%x, got path=%x", paths[i], corrupt.Path)
+			}
+		}
+
+		if len(report.Corrupt) != 2 || len(report.Decode) != 1 || len(report.Missing) != 1 {
+			t.Errorf("Expected 2 corrupt, 1 undecodable and 1 missing nodes, got report=%+v", report)
+		}
+
+		// The orphan and the descendants of the corrupt, undecodable and missing nodes are not reachable.
+		found := false
+		for _, key := range report.Orphans {
+			found = found || bytes.Equal(key, orphan)
+		}
+
+		if !found {
+			t.Errorf("Expected orphan at key=%x, got orphans=%x", orphan, report.Orphans)
+		}
+	})
+
+	t.Run("Verify[shrunk_nodes]", func(t *testing.T) {
+		t.Parallel()
+
+		db := store.NewMemoryDB()
+		mpt := NewEmptyTrie(db)
+
+		large := bytes.Repeat([]byte{0xff}, 40)
+		for _, key := range []string{"bb", "ba", "a", "c"} {
+			mpt.Put([]byte(key), []byte(key))
+		}
+
+		mpt.Put([]byte("d"), large)
+
+		if _, err := mpt.Commit(); err != nil {
+			t.Fatalf("Expected trie to be committed, got err=%s", err)
+		}
+
+		// Both changes leave a stored node small enough to be embedded in its parent.
+		if err := mpt.Del([]byte("bb")); err != nil {
+			t.Fatalf("Expected key=bb to be deleted, got err=%s", err)
+		}
+
+		mpt.Put([]byte("d"), []byte("d"))
+
+		root, err := mpt.Commit()
+		if err != nil {
+			t.Fatalf("Expected trie to be committed, got err=%s", err)
+		}
+
+		report, err := Verify(db, root)
+		if err != nil {
+			t.Fatalf("Expected trie to be verified, got err=%s", err)
+		}
+
+		if !report.OK() {
+			t.Errorf("Expected a sound trie, got report=%+v orphans=%x", report, report.Orphans)
+		}
+	})
+
+	t.Run("Verify[hash_scheme]", func(t *testing.T) {
+		t.Parallel()
+
+		db := store.NewMemoryDB()
+		mpt := trieFixture(t, db).(*Trie)
+		mpt.scheme = HashScheme
+
+		oldRoot, err := mpt.Commit()
+		if err != nil {
+			t.Fatalf("Expected trie to be committed, got err=%s", err)
+		}
+
+		mpt.Put([]byte("dog"), []byte("<new_val>"))
+
+		newRoot, err := mpt.Commit()
+		if err != nil {
+			t.Fatalf("Expected trie to be committed, got err=%s", err)
+		}
+
+		// Reference counts are not nodes.
+		if err = NewNodeDB(db, WithScheme(HashScheme)).Reference(newRoot); err != nil {
+			t.Fatalf("Expected root to be referenced, got err=%s", err)
+		}
+
+		report, err := Verify(db, newRoot, WithScheme(HashScheme))
+		if err != nil {
+			t.Fatalf("Expected trie to be verified, got err=%s", err)
+		}
+
+		// The nodes only referenced by the old root are orphaned.
+		found := false
+		for _, key := range report.Orphans {
+			found = found || bytes.Equal(key, oldRoot)
+		}
+
+		if !found || report.Nodes == 0 {
+			t.Errorf("Expected the old root to be orphaned, got report=%+v", report)
+		}
+	})
+}